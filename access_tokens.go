@@ -0,0 +1,192 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Scopes which may be granted to a personal access token.
+const (
+	ScopeRepoAdmin      = "repo:admin"
+	ScopeRepoWrite      = "repo:write"
+	ScopeRepoRead       = "repo:read"
+	ScopeRepoPublicRead = "repo:public_read"
+)
+
+// AccessTokenService handles communication with the personal access
+// token related methods of the Dockerhub API.
+type AccessTokenService service
+
+// AccessToken represents a Dockerhub personal access token. Token only
+// holds the plaintext secret in the response to CreateAccessToken; it is
+// empty everywhere else.
+type AccessToken struct {
+	UUID        string    `json:"uuid"`
+	ClientID    string    `json:"client_id"`
+	CreatorIP   string    `json:"creator_ip"`
+	CreatorUA   string    `json:"creator_ua"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsed    time.Time `json:"last_used"`
+	GeneratedBy string    `json:"generated_by"`
+	IsActive    bool      `json:"is_active"`
+	Token       string    `json:"token"`
+	TokenLabel  string    `json:"token_label"`
+	Scopes      []string  `json:"scopes"`
+}
+
+// AccessTokenList represents a list of access tokens with pagination
+// details.
+type AccessTokenList struct {
+	Count       int     `json:"count"`
+	Next        *string `json:"next"`
+	Previous    *string `json:"previous"`
+	ActiveCount int     `json:"active_count"`
+
+	Results []AccessToken `json:"results"`
+}
+
+// CreateAccessTokenRequest is request payload to create an AccessToken.
+type CreateAccessTokenRequest struct {
+	TokenLabel string   `json:"token_label"`
+	Scopes     []string `json:"scopes"`
+}
+
+// AccessTokenPatch represents payload to patch an AccessToken. Only the
+// non-nil fields are sent.
+type AccessTokenPatch struct {
+	TokenLabel *string `json:"token_label,omitempty"`
+	IsActive   *bool   `json:"is_active,omitempty"`
+}
+
+func (s AccessTokenService) buildAccessTokenSlug(uuid string) string {
+	return fmt.Sprintf("/access-tokens/%s/", uuid)
+}
+
+// CreateAccessToken creates a personal access token with the given label
+// and scopes. The returned AccessToken is the only place the plaintext
+// token is ever available.
+func (s *AccessTokenService) CreateAccessToken(ctx context.Context, label string, scopes []string) (*AccessToken, error) {
+	ctx = withOperation(ctx, "AccessTokens.CreateAccessToken", "", "")
+	req, err := s.client.NewRequest(http.MethodPost, "/access-tokens/", &CreateAccessTokenRequest{
+		TokenLabel: label,
+		Scopes:     scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccessToken{}
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetAccessTokens gets a page of the personal access tokens of the
+// logged in user.
+func (s *AccessTokenService) GetAccessTokens(ctx context.Context, page, pageSize int) (*AccessTokenList, error) {
+	ctx = withOperation(ctx, "AccessTokens.GetAccessTokens", "", "")
+	slug := fmt.Sprintf("/access-tokens/?page=%d&page_size=%d", page, pageSize)
+	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccessTokenList{}
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetAccessToken gets details for a given access token.
+func (s *AccessTokenService) GetAccessToken(ctx context.Context, uuid string) (*AccessToken, error) {
+	ctx = withOperation(ctx, "AccessTokens.GetAccessToken", "", "")
+	req, err := s.client.NewRequest(http.MethodGet, s.buildAccessTokenSlug(uuid), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccessToken{}
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateAccessToken updates an access token.
+func (s *AccessTokenService) UpdateAccessToken(ctx context.Context, uuid string, patch *AccessTokenPatch) (*AccessToken, error) {
+	ctx = withOperation(ctx, "AccessTokens.UpdateAccessToken", "", "")
+	req, err := s.client.NewRequest(http.MethodPatch, s.buildAccessTokenSlug(uuid), patch)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccessToken{}
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetAccessTokenActive enables or disables an access token.
+func (s *AccessTokenService) SetAccessTokenActive(ctx context.Context, uuid string, isActive bool) (*AccessToken, error) {
+	ctx = withOperation(ctx, "AccessTokens.SetAccessTokenActive", "", "")
+	return s.UpdateAccessToken(ctx, uuid, &AccessTokenPatch{IsActive: Bool(isActive)})
+}
+
+// RenameAccessToken changes the label of an access token.
+func (s *AccessTokenService) RenameAccessToken(ctx context.Context, uuid, label string) (*AccessToken, error) {
+	ctx = withOperation(ctx, "AccessTokens.RenameAccessToken", "", "")
+	return s.UpdateAccessToken(ctx, uuid, &AccessTokenPatch{TokenLabel: String(label)})
+}
+
+// DeleteAccessToken deletes an access token.
+func (s *AccessTokenService) DeleteAccessToken(ctx context.Context, uuid string) error {
+	ctx = withOperation(ctx, "AccessTokens.DeleteAccessToken", "", "")
+	req, err := s.client.NewRequest(http.MethodDelete, s.buildAccessTokenSlug(uuid), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.client.Do(ctx, req, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RotateToken replaces an access token with a new one carrying the same
+// label and scopes. The new token is passed to verify before the old one
+// is deleted; if verify returns an error the new token is deleted instead
+// and the old one is left untouched.
+func (s *AccessTokenService) RotateToken(ctx context.Context, uuid string, verify func(context.Context, *AccessToken) error) (*AccessToken, error) {
+	ctx = withOperation(ctx, "AccessTokens.RotateToken", "", "")
+	if verify == nil {
+		return nil, errors.New("verify callback is required")
+	}
+
+	old, err := s.GetAccessToken(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.CreateAccessToken(ctx, old.TokenLabel, old.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verify(ctx, token); err != nil {
+		if delErr := s.DeleteAccessToken(ctx, token.UUID); delErr != nil {
+			return nil, fmt.Errorf("verifying new token: %v (cleanup failed: %v)", err, delErr)
+		}
+		return nil, fmt.Errorf("verifying new token: %w", err)
+	}
+
+	if err := s.DeleteAccessToken(ctx, uuid); err != nil {
+		return token, fmt.Errorf("deleting old token: %w", err)
+	}
+	return token, nil
+}
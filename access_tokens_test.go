@@ -0,0 +1,183 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAccessTokenPatchOmitsNilFields(t *testing.T) {
+	assertMarshalledJSON(t, &AccessTokenPatch{}, "{}")
+	assertMarshalledJSON(t, &AccessTokenPatch{
+		TokenLabel: String("ci"),
+		IsActive:   Bool(false),
+	}, `{"token_label":"ci","is_active":false}`)
+}
+
+func TestAccessTokenService_CreateAccessToken(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	label := "ci"
+	scopes := []string{ScopeRepoRead, ScopeRepoWrite}
+	token := &AccessToken{
+		UUID:       "b30bbf97-506c-4ecd-aabc-842f3cb484fb",
+		IsActive:   true,
+		Token:      "a36b2a2c-4bb0-4a3e-a2e9-7e7e0d2c1b08",
+		TokenLabel: label,
+		Scopes:     scopes,
+	}
+
+	mux.HandleFunc("/access-tokens/", func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPost)
+		assertBody(t, r, string(mustJSONMarshal(&CreateAccessTokenRequest{
+			TokenLabel: label,
+			Scopes:     scopes,
+		})))
+		w.WriteHeader(http.StatusCreated)
+		w.Write(mustJSONMarshal(token))
+	})
+
+	res, err := client.AccessTokens.CreateAccessToken(context.Background(), label, scopes)
+	if err != nil {
+		t.Errorf("AccessTokens.CreateAccessToken returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, token) {
+		t.Errorf("access token is %v; want %v", res, token)
+	}
+}
+
+func TestAccessTokenService_GetAccessTokens(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	list := &AccessTokenList{Count: 1, ActiveCount: 1, Results: []AccessToken{{UUID: "uuid"}}}
+
+	mux.HandleFunc("/access-tokens/", func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		if got := r.URL.RawQuery; got != "page=2&page_size=10" {
+			t.Errorf("query is %s; want page=2&page_size=10", got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(mustJSONMarshal(list))
+	})
+
+	res, err := client.AccessTokens.GetAccessTokens(context.Background(), 2, 10)
+	if err != nil {
+		t.Errorf("AccessTokens.GetAccessTokens returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, list) {
+		t.Errorf("access token list is %v; want %v", res, list)
+	}
+}
+
+func TestAccessTokenService_SetAccessTokenActive(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	uuid := "uuid"
+	token := &AccessToken{UUID: uuid}
+
+	mux.HandleFunc(fmt.Sprintf("/access-tokens/%s/", uuid), func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPatch)
+		assertBody(t, r, `{"is_active":false}`+"\n")
+		w.WriteHeader(http.StatusOK)
+		w.Write(mustJSONMarshal(token))
+	})
+
+	res, err := client.AccessTokens.SetAccessTokenActive(context.Background(), uuid, false)
+	if err != nil {
+		t.Errorf("AccessTokens.SetAccessTokenActive returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, token) {
+		t.Errorf("access token is %v; want %v", res, token)
+	}
+}
+
+func TestAccessTokenService_DeleteAccessToken(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	uuid := "uuid"
+	mux.HandleFunc(fmt.Sprintf("/access-tokens/%s/", uuid), func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodDelete)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.AccessTokens.DeleteAccessToken(context.Background(), uuid); err != nil {
+		t.Errorf("AccessTokens.DeleteAccessToken returned error: %v", err)
+	}
+}
+
+func TestAccessTokenService_RotateToken(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		verifyErr error
+		deleted   string
+	}{
+		{"verified", nil, "old"},
+		{"rejected", errors.New("bad token"), "new"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, mux, teardown := makeMockClient()
+			defer teardown()
+
+			old := &AccessToken{UUID: "old", TokenLabel: "ci", Scopes: []string{ScopeRepoRead}}
+			created := &AccessToken{UUID: "new", TokenLabel: "ci", Scopes: []string{ScopeRepoRead}, Token: "secret"}
+
+			var deleted []string
+			mux.HandleFunc("/access-tokens/", func(w http.ResponseWriter, r *http.Request) {
+				assertMethod(t, r, http.MethodPost)
+				assertBody(t, r, string(mustJSONMarshal(&CreateAccessTokenRequest{
+					TokenLabel: old.TokenLabel,
+					Scopes:     old.Scopes,
+				})))
+				w.WriteHeader(http.StatusCreated)
+				w.Write(mustJSONMarshal(created))
+			})
+			mux.HandleFunc("/access-tokens/old/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					deleted = append(deleted, "old")
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.Write(mustJSONMarshal(old))
+			})
+			mux.HandleFunc("/access-tokens/new/", func(w http.ResponseWriter, r *http.Request) {
+				assertMethod(t, r, http.MethodDelete)
+				deleted = append(deleted, "new")
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			res, err := client.AccessTokens.RotateToken(context.Background(), "old", func(_ context.Context, tok *AccessToken) error {
+				if tok.Token != "secret" {
+					t.Errorf("verify got token %q; want secret", tok.Token)
+				}
+				return tc.verifyErr
+			})
+
+			if tc.verifyErr != nil {
+				if !errors.Is(err, tc.verifyErr) {
+					t.Errorf("AccessTokens.RotateToken error is %v; want %v", err, tc.verifyErr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("AccessTokens.RotateToken returned error: %v", err)
+				}
+				if !reflect.DeepEqual(res, created) {
+					t.Errorf("access token is %v; want %v", res, created)
+				}
+			}
+
+			if want := []string{tc.deleted}; !reflect.DeepEqual(deleted, want) {
+				t.Errorf("deleted tokens are %v; want %v", deleted, want)
+			}
+		})
+	}
+}
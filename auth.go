@@ -26,6 +26,7 @@ type LoginResponse struct {
 // Login authenticates with the Dockerhub API with the given given
 // username and password.
 func (s *AuthService) Login(ctx context.Context, username, password string) error {
+	ctx = withOperation(ctx, "Auth.Login", "", "")
 	p := &LoginRequest{username, password}
 	req, err := s.client.NewRequest(http.MethodPost, "/users/login/", p)
 	if err != nil {
@@ -0,0 +1,226 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultBatchConcurrency is the number of calls a Batch runs at once
+// when BatchOptions gives no limit.
+const defaultBatchConcurrency = 4
+
+// defaultBatchRetries is the number of times a Batch retries an item
+// rejected with 429 Too Many Requests when BatchOptions gives no limit.
+const defaultBatchRetries = 3
+
+// defaultRateLimitPause is how long requests pause after a 429 Too Many
+// Requests response which does not say when to retry.
+const defaultRateLimitPause = 10 * time.Second
+
+// BatchOptions configures a Batch.
+type BatchOptions struct {
+	// Concurrency is the number of calls run at once. It defaults to 4.
+	Concurrency int
+
+	// MaxRetries is the number of times an item rejected with 429 Too
+	// Many Requests is retried. It defaults to 3; a negative value
+	// disables retries, which functions that are not idempotent need.
+	MaxRetries int
+}
+
+// BatchResult is the outcome of calling a Batch function for one item.
+type BatchResult[T, R any] struct {
+	Item  T
+	Value R
+	Err   error
+}
+
+// BatchError reports the items of a Batch which failed.
+type BatchError struct {
+	// Errors holds the error of each item, in the order of the items,
+	// with nil for those which succeeded.
+	Errors []error
+	Failed int
+}
+
+func (e *BatchError) Error() string {
+	for _, err := range e.Errors {
+		if err != nil {
+			return fmt.Sprintf("%d of %d batch items failed; first error: %v", e.Failed, len(e.Errors), err)
+		}
+	}
+	return "batch failed"
+}
+
+// Unwrap returns the errors of the failed items.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Batch calls fn for each item, running up to opts.Concurrency calls at
+// once, for example to fetch the tags of every repository in a
+// namespace:
+//
+//	results, err := dockerhub.Batch(ctx, client, repos.Results, nil,
+//		func(ctx context.Context, r dockerhub.Repository) (*dockerhub.Tags, error) {
+//			return client.Tag.GetTags(ctx, r.Namespace, r.Name, 100)
+//		})
+//
+// fn should make its requests with client. When one of them is rejected
+// with 429 Too Many Requests, every call waits until the rate limit
+// resets before sending more, and the rejected item is retried by calling
+// fn again from the start. fn must therefore be idempotent: requests it
+// made before the rejected one are sent again, so a function creating a
+// repository and then its webhooks should check for what exists, or set
+// MaxRetries to -1 and retry failed items itself. Once ctx is done no more
+// items are started, and those left fail with its error.
+//
+// The results are in the order of items. If any item failed, the error
+// is a *BatchError.
+func Batch[T, R any](ctx context.Context, client *Client, items []T, opts *BatchOptions, fn func(ctx context.Context, item T) (R, error)) ([]BatchResult[T, R], error) {
+	concurrency, retries := defaultBatchConcurrency, defaultBatchRetries
+	if opts != nil {
+		if opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+		if opts.MaxRetries != 0 {
+			retries = opts.MaxRetries
+		}
+	}
+
+	results := make([]BatchResult[T, R], len(items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i].Value, results[i].Err = batchCall(ctx, client, items[i], retries, fn)
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(items); next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	batchErr := &BatchError{Errors: make([]error, len(items))}
+	for i := range results {
+		results[i].Item = items[i]
+		if i >= next {
+			results[i].Err = ctx.Err()
+		}
+		if results[i].Err != nil {
+			batchErr.Errors[i] = results[i].Err
+			batchErr.Failed++
+		}
+	}
+	if batchErr.Failed > 0 {
+		return results, batchErr
+	}
+	return results, nil
+}
+
+// batchCall calls fn for item, retrying when it is rate limited.
+func batchCall[T, R any](ctx context.Context, client *Client, item T, retries int, fn func(context.Context, T) (R, error)) (R, error) {
+	ctx = context.WithValue(ctx, rateLimitWaitKey{}, true)
+	for attempt := 0; ; attempt++ {
+		if err := client.waitRateLimit(ctx); err != nil {
+			var zero R
+			return zero, err
+		}
+
+		v, err := fn(ctx, item)
+		var errResp *ErrorResponse
+		if err == nil || attempt >= retries || !errors.As(err, &errResp) || errResp.StatusCode != http.StatusTooManyRequests {
+			return v, err
+		}
+	}
+}
+
+// rateLimitReset returns when requests may resume after resp was
+// rejected with 429 Too Many Requests, from its Retry-After or
+// X-RateLimit-Reset header.
+func rateLimitReset(resp *http.Response, now time.Time) time.Time {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t
+		}
+	}
+	if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+		if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(epoch, 0)
+		}
+	}
+	return now.Add(defaultRateLimitPause)
+}
+
+type rateLimitWaitKey struct{}
+
+// waitsForRateLimit reports whether requests made with ctx wait while the
+// client is paused by a 429 Too Many Requests response.
+func waitsForRateLimit(ctx context.Context) bool {
+	wait, _ := ctx.Value(rateLimitWaitKey{}).(bool)
+	return wait
+}
+
+// pauseUntil holds back requests made through waitRateLimit until t.
+func (c *Client) pauseUntil(t time.Time) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	if t.After(c.pausedUntil) {
+		c.pausedUntil = t
+	}
+}
+
+// waitRateLimit waits until the client is no longer paused by a 429 Too
+// Many Requests response, or ctx is done.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	for {
+		c.rateMu.Lock()
+		wait := c.pausedUntil.Sub(c.now())
+		c.rateMu.Unlock()
+
+		if wait <= 0 {
+			return ctx.Err()
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,173 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func getTagsOf(client *Client) func(context.Context, string) (*Tags, error) {
+	return func(ctx context.Context, repo string) (*Tags, error) {
+		return client.Tag.GetTags(ctx, "someone", repo, 10)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	mux.HandleFunc("/repositories/someone/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		repo := strings.Split(r.URL.Path, "/")[3]
+		if repo == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(mustJSONMarshal(&Tags{Count: 1, Results: []Tag{{Name: repo}}}))
+	})
+
+	repos := []string{"a", "b", "missing", "c", "d", "e", "f", "g"}
+	results, err := Batch(context.Background(), client, repos, &BatchOptions{Concurrency: 3}, getTagsOf(client))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 1 {
+		t.Fatalf("Batch returned error %v; want one failed item", err)
+	}
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusNotFound {
+		t.Errorf("Batch error does not wrap the 404: %v", err)
+	}
+	for i, r := range results {
+		if r.Item != repos[i] {
+			t.Errorf("result %d is for %s; want %s", i, r.Item, repos[i])
+		}
+		if r.Item == "missing" {
+			if r.Err == nil {
+				t.Error("missing repository has no error")
+			}
+			continue
+		}
+		if r.Err != nil || r.Value.Results[0].Name != r.Item {
+			t.Errorf("result for %s is %+v, %v", r.Item, r.Value, r.Err)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("%d requests ran at once; want at most 3", maxInFlight)
+	}
+}
+
+func TestBatch_RateLimited(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	// Waiting out the pause moves the clock forward instead of sleeping.
+	var mu sync.Mutex
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	client.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+		return ctx.Err()
+	}
+
+	var limitedAt time.Time
+	var early []string
+	mux.HandleFunc("/repositories/someone/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		repo := strings.Split(r.URL.Path, "/")[3]
+		if repo == "b" && limitedAt.IsZero() {
+			limitedAt = now
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if !limitedAt.IsZero() && now.Before(limitedAt.Add(time.Second)) {
+			early = append(early, repo)
+		}
+		w.Write(mustJSONMarshal(&Tags{}))
+	})
+
+	// The other calls wait for the 429, so that they are sent after it
+	// and must wait for the limit to reset.
+	getTags := getTagsOf(client)
+	limited := make(chan struct{})
+	var once sync.Once
+	var calls []string
+	results, err := Batch(context.Background(), client, []string{"a", "b", "c", "d"}, &BatchOptions{Concurrency: 2},
+		func(ctx context.Context, repo string) (*Tags, error) {
+			mu.Lock()
+			calls = append(calls, repo)
+			mu.Unlock()
+			if repo != "b" {
+				<-limited
+				return getTags(ctx, repo)
+			}
+			defer once.Do(func() { close(limited) })
+			return getTags(ctx, repo)
+		})
+	if err != nil {
+		t.Fatalf("Batch returned error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("Batch returned %d results; want 4", len(results))
+	}
+	if len(early) > 0 {
+		t.Errorf("requests for %v were sent before the rate limit reset", early)
+	}
+	if len(calls) != 5 {
+		t.Errorf("fn was called for %v; want b retried once", calls)
+	}
+}
+
+func TestBatch_Cancel(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	mux.HandleFunc("/repositories/someone/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mustJSONMarshal(&Tags{}))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	getTags := getTagsOf(client)
+	results, err := Batch(ctx, client, []string{"a", "b", "c"}, &BatchOptions{Concurrency: 1},
+		func(ctx context.Context, repo string) (*Tags, error) {
+			defer cancel()
+			return getTags(ctx, repo)
+		})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 2 {
+		t.Fatalf("Batch returned error %v; want two failed items", err)
+	}
+	if results[0].Err != nil {
+		t.Errorf("first item failed: %v", results[0].Err)
+	}
+	for _, r := range results[1:] {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("item %s has error %v; want context.Canceled", r.Item, r.Err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultBreakerThreshold is the number of consecutive failures which
+// open a circuit breaker when CircuitBreakerOptions gives none.
+const defaultBreakerThreshold = 5
+
+// defaultBreakerCooldown is how long a circuit breaker stays open when
+// CircuitBreakerOptions gives no cooldown.
+const defaultBreakerCooldown = 30 * time.Second
+
+// ErrCircuitOpen is matched by the errors of requests a circuit breaker
+// rejected without sending.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned for requests a circuit breaker rejected
+// without sending. errors.Is reports it as ErrCircuitOpen.
+type CircuitOpenError struct {
+	// RetryAt is when the breaker next lets a request through.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v until %s", ErrCircuitOpen, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until its cooldown passes.
+	CircuitOpen
+	// CircuitHalfOpen lets a single request through to probe whether the
+	// API has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerOptions configures a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// Threshold is the number of consecutive failures which open the
+	// breaker. It defaults to 5.
+	Threshold int
+
+	// Cooldown is how long the breaker stays open before letting a probe
+	// request through. It defaults to 30 seconds.
+	Cooldown time.Duration
+
+	// OnStateChange, if set, is called when the breaker changes state.
+	OnStateChange func(from, to CircuitState)
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// CircuitBreaker stops a Client from sending requests while Dockerhub is
+// failing. Server errors, timeouts and connection failures count as
+// failures; once Threshold of them happen in a row the breaker opens and
+// requests fail fast with a *CircuitOpenError. After the cooldown a single
+// request is let through: if it succeeds the breaker closes, and if not
+// it opens again. Only requests which reach the network count, so waiting
+// on a RateLimiter or a response served by middleware does not. It is safe
+// for concurrent use.
+type CircuitBreaker struct {
+	opts CircuitBreakerOptions
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	// generation counts the state changes of the breaker, so that the
+	// outcome of a request can be told apart from those let through
+	// before the breaker last changed state.
+	generation uint64
+}
+
+// NewCircuitBreaker returns a closed circuit breaker.
+func NewCircuitBreaker(opts *CircuitBreakerOptions) *CircuitBreaker {
+	b := &CircuitBreaker{}
+	if opts != nil {
+		b.opts = *opts
+	}
+	if b.opts.Threshold <= 0 {
+		b.opts.Threshold = defaultBreakerThreshold
+	}
+	if b.opts.Cooldown <= 0 {
+		b.opts.Cooldown = defaultBreakerCooldown
+	}
+	if b.opts.Now == nil {
+		b.opts.Now = time.Now
+	}
+	return b
+}
+
+// State returns the state of the breaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState changes the state of the breaker, returning a function which
+// reports the change. It is called without the lock held, so that
+// callbacks may use the breaker.
+func (b *CircuitBreaker) setState(to CircuitState) func() {
+	from := b.state
+	b.state = to
+	if from != to {
+		b.generation++
+	}
+	if from == to || b.opts.OnStateChange == nil {
+		return func() {}
+	}
+	return func() { b.opts.OnStateChange(from, to) }
+}
+
+// allow reports whether a request may be sent, returning a
+// *CircuitOpenError if not. Each allowed request must be followed by a
+// call to record with the generation allow returned.
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	notify := func() {}
+	defer func() {
+		b.mu.Unlock()
+		notify()
+	}()
+
+	switch b.state {
+	case CircuitOpen:
+		retryAt := b.openedAt.Add(b.opts.Cooldown)
+		if b.opts.Now().Before(retryAt) {
+			return 0, &CircuitOpenError{RetryAt: retryAt}
+		}
+		notify = b.setState(CircuitHalfOpen)
+		b.probing = true
+	case CircuitHalfOpen:
+		if b.probing {
+			return 0, &CircuitOpenError{RetryAt: b.opts.Now()}
+		}
+		b.probing = true
+	}
+	return b.generation, nil
+}
+
+// record counts the outcome of a request allowed in generation.
+func (b *CircuitBreaker) record(generation uint64, resp *http.Response, err error) {
+	b.mu.Lock()
+	notify := func() {}
+	defer func() {
+		b.mu.Unlock()
+		notify()
+	}()
+
+	if b.state != CircuitClosed && generation != b.generation {
+		// The request was let through before the breaker opened. Its
+		// success shows the API answering again, but must not cut the
+		// cooldown short or take the place of the probe.
+		if !errors.Is(err, context.Canceled) && !isFailure(resp, err) {
+			b.failures = 0
+		}
+		return
+	}
+
+	probe := b.state == CircuitHalfOpen
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up; this says nothing about the API.
+	case isFailure(resp, err):
+		b.failures++
+		if probe || b.failures >= b.opts.Threshold {
+			b.openedAt = b.opts.Now()
+			notify = b.setState(CircuitOpen)
+		}
+	default:
+		b.failures = 0
+		notify = b.setState(CircuitClosed)
+	}
+}
+
+// isFailure reports whether the outcome of a request suggests the API is
+// unavailable: it was not answered, or answered with a server error.
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= 500
+}
@@ -0,0 +1,197 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var changes []string
+	breaker := NewCircuitBreaker(&CircuitBreakerOptions{
+		Threshold: 3,
+		Cooldown:  time.Minute,
+		Now:       func() time.Time { return now },
+		OnStateChange: func(from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%v->%v", from, to))
+		},
+	})
+	client.CircuitBreaker = breaker
+
+	status, requests := http.StatusServiceUnavailable, 0
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	})
+	get := func() error {
+		_, err := client.User.GetLoggedInUser(context.Background())
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := get(); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d failed fast before the threshold", i)
+		}
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("breaker is %v after 3 failures; want open", breaker.State())
+	}
+
+	err := get()
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || !openErr.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("request while open returned %v; want ErrCircuitOpen until the cooldown ends", err)
+	}
+	if requests != 3 {
+		t.Errorf("server got %d requests; want 3", requests)
+	}
+
+	// A failed probe opens the breaker again.
+	now = now.Add(time.Minute)
+	get()
+	if breaker.State() != CircuitOpen || requests != 4 {
+		t.Errorf("breaker is %v after a failed probe, with %d requests; want open with 4", breaker.State(), requests)
+	}
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	status = http.StatusOK
+	if err := get(); err != nil {
+		t.Errorf("probe returned error: %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("breaker is %v after a successful probe; want closed", breaker.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("state changes are %v; want %v", changes, want)
+	}
+}
+
+func TestCircuitBreaker_ClientErrors(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	client.CircuitBreaker = NewCircuitBreaker(&CircuitBreakerOptions{Threshold: 1})
+
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	for i := 0; i < 3; i++ {
+		client.User.GetLoggedInUser(context.Background())
+	}
+	if state := client.CircuitBreaker.State(); state != CircuitClosed {
+		t.Errorf("breaker is %v after 404s; want closed", state)
+	}
+}
+
+func TestCircuitBreaker_RateLimiterWait(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(&CircuitBreakerOptions{
+		Threshold: 1,
+		Cooldown:  time.Minute,
+		Now:       func() time.Time { return now },
+	})
+	client.CircuitBreaker = breaker
+
+	status, requests := http.StatusOK, 0
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	})
+
+	// Running out of time waiting on the local rate limiter says nothing
+	// about Dockerhub.
+	limiter := NewRateLimiter(0.001, 1)
+	limiter.reserve(time.Now())
+	client.RateLimiter = limiter
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.User.GetLoggedInUser(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("rate limited request returned %v; want context.DeadlineExceeded", err)
+	}
+	if breaker.State() != CircuitClosed || requests != 0 {
+		t.Errorf("breaker is %v after a rate limiter timeout, with %d requests; want closed with 0", breaker.State(), requests)
+	}
+
+	// Nor does waiting on it take the half-open probe.
+	client.RateLimiter = nil
+	status = http.StatusServiceUnavailable
+	client.User.GetLoggedInUser(context.Background())
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("breaker is %v after a failure; want open", breaker.State())
+	}
+	now = now.Add(time.Minute)
+	client.RateLimiter = limiter
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.User.GetLoggedInUser(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("rate limited request returned %v; want context.DeadlineExceeded", err)
+	}
+	client.RateLimiter = nil
+	status = http.StatusOK
+	if _, err := client.User.GetLoggedInUser(context.Background()); err != nil {
+		t.Errorf("probe after a rate limiter timeout returned error: %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("breaker is %v after a successful probe; want closed", breaker.State())
+	}
+}
+
+func TestCircuitBreaker_LateSuccess(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(&CircuitBreakerOptions{
+		Threshold: 2,
+		Cooldown:  time.Minute,
+		Now:       func() time.Time { return now },
+	})
+	failure := &http.Response{StatusCode: http.StatusServiceUnavailable}
+	success := &http.Response{StatusCode: http.StatusOK}
+
+	slow, _ := b.allow()
+	for i := 0; i < 2; i++ {
+		g, _ := b.allow()
+		b.record(g, failure, nil)
+	}
+	if b.State() != CircuitOpen {
+		t.Fatalf("breaker is %v after 2 failures; want open", b.State())
+	}
+
+	// A request sent before the breaker opened succeeding late resets the
+	// failures, but leaves the breaker open until its cooldown ends.
+	b.record(slow, success, nil)
+	if b.State() != CircuitOpen || b.failures != 0 {
+		t.Errorf("breaker is %v with %d failures after a late success; want open with 0", b.State(), b.failures)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow during the cooldown returned %v; want ErrCircuitOpen", err)
+	}
+
+	// Nor does it stand in for the probe once the breaker is half-open.
+	now = now.Add(time.Minute)
+	slow, _ = b.allow()
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("breaker is %v after the cooldown; want half-open", b.State())
+	}
+	b.record(0, success, nil)
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow with the probe in flight returned %v; want ErrCircuitOpen", err)
+	}
+	b.record(slow, failure, nil)
+	if b.State() != CircuitOpen {
+		t.Errorf("breaker is %v after a failed probe; want open", b.State())
+	}
+}
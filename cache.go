@@ -0,0 +1,376 @@
+package dockerhub
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheHeader is the response header CacheMiddleware reports how a
+// response was served in, as CacheHit, CacheRevalidated or CacheMiss.
+const CacheHeader = "X-Dockerhub-Cache"
+
+// Values of CacheHeader.
+const (
+	// CacheHit is a response served from the cache without a request.
+	CacheHit = "hit"
+	// CacheRevalidated is a cached response the API confirmed with a 304
+	// Not Modified.
+	CacheRevalidated = "revalidated"
+	// CacheMiss is a response received from the API.
+	CacheMiss = "miss"
+)
+
+// defaultCacheSize is the number of responses an LRUCache holds when
+// created with no size.
+const defaultCacheSize = 1024
+
+// defaultCacheTTL is how long responses without validators are cached
+// when CacheOptions gives no TTL.
+const defaultCacheTTL = time.Minute
+
+// CachedResponse is a response stored in a Cache.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+}
+
+// A Cache stores responses by key. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// LRUCache is an in-memory Cache evicting the least recently used
+// responses once it is full.
+type LRUCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewLRUCache returns a cache holding up to size responses, or 1024 if
+// size is not positive.
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	return &LRUCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the response stored under key.
+func (c *LRUCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).resp, true
+}
+
+// Set stores resp under key.
+func (c *LRUCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).resp = resp
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key, resp})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Delete removes the response stored under key.
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of responses in the cache.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// CacheOptions configures CacheMiddleware.
+type CacheOptions struct {
+	// Cache stores the responses. It defaults to an LRUCache of 1024
+	// responses.
+	Cache Cache
+
+	// TTL is how long a response without an ETag or Last-Modified header
+	// is served from the cache. It defaults to a minute. Responses with
+	// one are revalidated with a conditional request each time instead.
+	TTL time.Duration
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// CacheMiddleware caches successful GET responses with JSON or text
+// bodies, such as those of GetRepository and GetTags, and evicts them on
+// writes. CacheHeader reports how each response was served.
+func CacheMiddleware(opts *CacheOptions) Middleware {
+	c := &cacher{
+		written: make(map[string]time.Time),
+		touched: make(map[string]time.Time),
+	}
+	if opts != nil {
+		c.CacheOptions = *opts
+	}
+	if c.Cache == nil {
+		c.Cache = NewLRUCache(0)
+	}
+	if c.TTL <= 0 {
+		c.TTL = defaultCacheTTL
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return c.do(next, req)
+		})
+	}
+}
+
+type cacher struct {
+	CacheOptions
+
+	// written holds when each path was last written to, and touched when
+	// each path or one beneath it was, so that responses stored before
+	// then can be told to be stale.
+	mu      sync.Mutex
+	written map[string]time.Time
+	touched map[string]time.Time
+}
+
+type noCacheKey struct{}
+
+// withoutCache returns a context whose requests CacheMiddleware passes
+// through without caching. Registry requests use it, as their bearer
+// tokens change every few minutes.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// cachePath returns the host and path of u, without a trailing slash.
+func cachePath(u *url.URL) string {
+	return u.Host + strings.TrimSuffix(u.Path, "/")
+}
+
+// parentPath returns the path above p, or "" above the host.
+func parentPath(p string) string {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return ""
+	}
+	return p[:i]
+}
+
+// invalidate records a successful write to u, evicting the responses
+// cached for its path, for the paths above it and for those beneath it.
+// Setting a repository's privacy thus evicts both the repository and the
+// list of repositories in its namespace. Only writes sent through this
+// middleware are seen: a Cache shared with other clients may still serve
+// responses which their writes made stale.
+func (c *cacher) invalidate(u *url.URL) {
+	now := c.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Responses without validators are served no longer than the TTL,
+	// and the others are revalidated, so older writes no longer matter.
+	for p, t := range c.written {
+		if now.Sub(t) >= c.TTL {
+			delete(c.written, p)
+		}
+	}
+	for p, t := range c.touched {
+		if now.Sub(t) >= c.TTL {
+			delete(c.touched, p)
+		}
+	}
+
+	p := cachePath(u)
+	c.written[p] = now
+	for ; p != ""; p = parentPath(p) {
+		c.touched[p] = now
+	}
+}
+
+// stale reports whether a response to u stored at storedAt predates a
+// write to its path, a path above it or a path beneath it. Responses
+// with an ETag or Last-Modified header are otherwise revalidated, and
+// the others served until their TTL passes.
+func (c *cacher) stale(u *url.URL, storedAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := cachePath(u)
+	if t, ok := c.touched[p]; ok && !t.Before(storedAt) {
+		return true
+	}
+	for ; p != ""; p = parentPath(p) {
+		if t, ok := c.written[p]; ok && !t.Before(storedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey returns the key of the response to req. Responses are cached
+// per Authorization header, so users never see each other's responses.
+func cacheKey(req *http.Request) string {
+	auth := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + " " + hex.EncodeToString(auth[:8])
+}
+
+// validated reports whether resp has a validator for conditional
+// requests.
+func (r *CachedResponse) validated() bool {
+	return r.Header.Get("ETag") != "" || r.Header.Get("Last-Modified") != ""
+}
+
+func (c *cacher) do(next Doer, req *http.Request) (*http.Response, error) {
+	if skip, _ := req.Context().Value(noCacheKey{}).(bool); skip {
+		return next.Do(req)
+	}
+	key := cacheKey(req)
+
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		resp, err := next.Do(req)
+		if err == nil && req.Method != http.MethodHead && resp.StatusCode < 300 {
+			c.Cache.Delete(key)
+			c.invalidate(req.URL)
+		}
+		return resp, err
+	}
+
+	cached, ok := c.Cache.Get(key)
+	if ok && c.stale(req.URL, cached.StoredAt) {
+		c.Cache.Delete(key)
+		ok = false
+	}
+	if ok && !cached.validated() && c.Now().Sub(cached.StoredAt) < c.TTL {
+		return cached.response(req, CacheHit), nil
+	}
+
+	if ok && cached.validated() {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		refreshed := *cached
+		refreshed.Header = cached.Header.Clone()
+		for k, v := range resp.Header {
+			refreshed.Header[k] = v
+		}
+		refreshed.StoredAt = c.Now()
+		c.Cache.Set(key, &refreshed)
+		return refreshed.response(req, CacheRevalidated), nil
+	}
+
+	// Blobs such as image layers are not worth keeping in memory.
+	if resp.StatusCode != http.StatusOK || noStore(resp.Header) || !loggableBody(resp.Header.Get("Content-Type")) {
+		resp.Header.Set(CacheHeader, CacheMiss)
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	stored := &CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   c.Now(),
+	}
+	c.Cache.Set(key, stored)
+	return stored.response(req, CacheMiss), nil
+}
+
+// noStore reports whether the Cache-Control header forbids caching.
+func noStore(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-store":
+			return true
+		}
+	}
+	return false
+}
+
+// response returns a response to req with the cached status, headers and
+// body.
+func (r *CachedResponse) response(req *http.Request, status string) *http.Response {
+	header := r.Header.Clone()
+	header.Set(CacheHeader, status)
+	header.Set("Content-Length", strconv.Itoa(len(r.Body)))
+	return &http.Response{
+		Status:        strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,227 @@
+package dockerhub
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// getCacheStatus sends a GET for slug and returns how it was served.
+func getCacheStatus(t *testing.T, client *Client, slug string) string {
+	t.Helper()
+	req, err := client.NewRequest(http.MethodGet, slug, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &Repository{}
+	resp, err := client.Do(context.Background(), req, res)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if res.Name != "app" {
+		t.Errorf("repository is %+v; want the app repository", res)
+	}
+	return resp.Header.Get(CacheHeader)
+}
+
+func TestCacheMiddleware_ETag(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	client.Use(CacheMiddleware(nil))
+
+	requests := 0
+	mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"name":"app"}`))
+	})
+
+	for i, want := range []string{CacheMiss, CacheRevalidated, CacheRevalidated} {
+		if got := getCacheStatus(t, client, "/repositories/someone/app/"); got != want {
+			t.Errorf("request %d was a cache %s; want %s", i, got, want)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("server got %d requests; want 3", requests)
+	}
+}
+
+func TestCacheMiddleware_TTL(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	client.Use(CacheMiddleware(&CacheOptions{TTL: time.Minute, Now: func() time.Time { return now }}))
+
+	requests := 0
+	mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"name":"app"}`))
+	})
+
+	slug := "/repositories/someone/app/"
+	if got := getCacheStatus(t, client, slug); got != CacheMiss {
+		t.Errorf("first request was a cache %s; want miss", got)
+	}
+	now = now.Add(30 * time.Second)
+	if got := getCacheStatus(t, client, slug); got != CacheHit {
+		t.Errorf("request within the TTL was a cache %s; want hit", got)
+	}
+
+	// Another user must not be served the cached response.
+	client.SetAuthToken("other")
+	if got := getCacheStatus(t, client, slug); got != CacheMiss {
+		t.Errorf("request with other credentials was a cache %s; want miss", got)
+	}
+	client.SetAuthToken("")
+
+	now = now.Add(time.Minute)
+	if got := getCacheStatus(t, client, slug); got != CacheMiss {
+		t.Errorf("request after the TTL was a cache %s; want miss", got)
+	}
+	if requests != 3 {
+		t.Errorf("server got %d requests; want 3", requests)
+	}
+}
+
+func TestCacheMiddleware_WriteEvicts(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	cache := NewLRUCache(0)
+	client.Use(CacheMiddleware(&CacheOptions{Cache: cache}))
+
+	mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"app"}`))
+	})
+
+	getCacheStatus(t, client, "/repositories/someone/app/")
+	if cache.Len() != 1 {
+		t.Fatalf("cache holds %d responses; want 1", cache.Len())
+	}
+	if _, err := client.Repositories.EditRepository(context.Background(), "someone", "app", &RepositoryPatch{Description: "new"}); err != nil {
+		t.Fatalf("Repositories.EditRepository returned error: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("cache holds %d responses after an edit; want 0", cache.Len())
+	}
+}
+
+func TestCacheMiddleware_WriteEvictsRelated(t *testing.T) {
+	paths := []string{
+		"/repositories/someone/",
+		"/repositories/someone/app/",
+		"/repositories/someone/app/tags/?page_size=10",
+		"/repositories/someone/app/webhook_pipeline/",
+		"/repositories/other/app/",
+	}
+	for _, tc := range []struct {
+		name    string
+		write   func(*Client) error
+		evicted []bool
+	}{
+		{
+			"privacy",
+			func(c *Client) error {
+				return c.Repositories.SetRepositoryPrivacy(context.Background(), "someone", "app", true)
+			},
+			[]bool{true, true, false, false, false},
+		},
+		{
+			"create repository",
+			func(c *Client) error {
+				_, err := c.Repositories.CreateRepository(context.Background(), "someone", "new", "", false)
+				return err
+			},
+			[]bool{true, true, true, true, true},
+		},
+		{
+			"delete webhook",
+			func(c *Client) error {
+				return c.Webhook.DeleteWebhook(context.Background(), "someone", "app", "ci")
+			},
+			[]bool{true, true, false, true, false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, mux, teardown := makeMockClient()
+			defer teardown()
+
+			now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+			client.Use(CacheMiddleware(&CacheOptions{TTL: time.Hour, Now: func() time.Time { return now }}))
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.Write([]byte(`{"name":"app"}`))
+				}
+			})
+
+			for _, p := range paths {
+				getCacheStatus(t, client, p)
+			}
+			now = now.Add(time.Second)
+			if err := tc.write(client); err != nil {
+				t.Fatalf("write returned error: %v", err)
+			}
+			now = now.Add(time.Second)
+
+			for i, p := range paths {
+				want := CacheHit
+				if tc.evicted[i] {
+					want = CacheMiss
+				}
+				if got := getCacheStatus(t, client, p); got != want {
+					t.Errorf("%s after the write was a cache %s; want %s", p, got, want)
+				}
+			}
+			for _, p := range paths {
+				if got := getCacheStatus(t, client, p); got != CacheHit {
+					t.Errorf("%s once fetched again was a cache %s; want hit", p, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheMiddleware_SkipsRegistry(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+	cache := NewLRUCache(0)
+	client.Use(CacheMiddleware(&CacheOptions{Cache: cache}))
+
+	requests := 0
+	mux.HandleFunc("/v2/library/ubuntu/manifests/latest", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", MediaTypeOCIManifest)
+		w.Write([]byte(`{"schemaVersion":2,"mediaType":"` + MediaTypeOCIManifest + `"}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Registry.GetManifest(context.Background(), "library", "ubuntu", "latest"); err != nil {
+			t.Fatalf("Registry.GetManifest returned error: %v", err)
+		}
+	}
+	if requests != 2 || cache.Len() != 0 {
+		t.Errorf("server got %d requests with %d cached responses; want 2 with none", requests, cache.Len())
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", &CachedResponse{})
+	cache.Set("b", &CachedResponse{})
+	cache.Get("a")
+	cache.Set("c", &CachedResponse{})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("least recently used response was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("response %s was evicted", key)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// Environment variables which override the config file.
+const (
+	envUsername = "DOCKERHUB_USERNAME"
+	envPassword = "DOCKERHUB_PASSWORD"
+	envToken    = "DOCKERHUB_TOKEN"
+	envAPIURL   = "DOCKERHUB_API_URL"
+	envConfig   = "DOCKERHUB_CONFIG"
+)
+
+// config holds the credentials used by the CLI. A token takes precedence
+// over a username and password.
+type config struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	APIURL   string `json:"api_url,omitempty"`
+}
+
+// defaultConfigPath returns the path of the config file, which may be
+// overridden with DOCKERHUB_CONFIG.
+func defaultConfigPath() string {
+	if p := os.Getenv(envConfig); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "dockerhub", "config.json")
+}
+
+// readConfigFile reads the config file at path. A missing file yields
+// an empty config.
+func readConfigFile(path string) (*config, error) {
+	cfg := &config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadConfig reads the config file at path, if any, and applies the
+// environment on top of it.
+func loadConfig(path string) (*config, error) {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := os.Getenv(envUsername); v != "" {
+		cfg.Username = v
+	}
+	if v := os.Getenv(envPassword); v != "" {
+		cfg.Password = v
+	}
+	if v := os.Getenv(envToken); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv(envAPIURL); v != "" {
+		cfg.APIURL = v
+	}
+	return cfg, nil
+}
+
+// save writes the config file at path, readable only by its owner.
+func (cfg *config) save(path string) error {
+	if path == "" {
+		return errors.New("no config file path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
+
+// newClient returns a client authenticated with the configured token, or
+// by logging in with the configured username and password.
+func (cfg *config) newClient(ctx context.Context) (*dockerhub.Client, error) {
+	client := dockerhub.NewClient(nil)
+	if cfg.APIURL != "" {
+		u, err := url.Parse(strings.TrimSuffix(cfg.APIURL, "/"))
+		if err != nil {
+			return nil, err
+		}
+		client.BaseURL = u
+	}
+
+	switch {
+	case cfg.Token != "":
+		client.SetAuthToken(cfg.Token)
+	case cfg.Username != "" && cfg.Password != "":
+		if err := client.Auth.Login(ctx, cfg.Username, cfg.Password); err != nil {
+			return nil, err
+		}
+	}
+	return client, nil
+}
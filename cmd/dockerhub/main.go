@@ -0,0 +1,416 @@
+// Command dockerhub is a command-line client for the Dockerhub API.
+//
+// Credentials are read from the config file written by "dockerhub login"
+// and may be overridden with the DOCKERHUB_TOKEN, or DOCKERHUB_USERNAME and
+// DOCKERHUB_PASSWORD, environment variables.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+const usage = `Usage: dockerhub [-o table|json] [-config path] <command> [arguments]
+
+Commands:
+  login                                   log in and save the token
+  repo list <namespace>                   list repositories
+  repo get <namespace/repo>               show a repository
+  repo create [-description d] [-private] <namespace/repo>
+  repo edit [-description d] [-readme file] <namespace/repo>
+  repo privacy <namespace/repo> public|private
+  tag list [-n page size] <namespace/repo>
+  webhook create <namespace/repo> <name> <url>
+  webhook list <namespace/repo>
+  webhook delete <namespace/repo> <slug>
+  org create [-company c] <name>
+  org list [-n page size]
+`
+
+// errUsage reports a command invoked with the wrong arguments.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("dockerhub", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	output := fs.String("o", "table", "output format: table or json")
+	configPath := fs.String("config", defaultConfigPath(), "config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unknown output format %q\n", *output)
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "reading config: %v\n", err)
+		return 1
+	}
+
+	c := &cli{
+		cfg:        cfg,
+		configPath: *configPath,
+		out:        &printer{w: stdout, json: *output == "json"},
+		stdout:     stdout,
+	}
+	if err := c.dispatch(ctx, fs.Args()); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "dockerhub: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// cli holds the state shared by the commands.
+type cli struct {
+	cfg        *config
+	configPath string
+	out        *printer
+	stdout     io.Writer
+	client     *dockerhub.Client
+}
+
+// dispatch runs the command named by args.
+func (c *cli) dispatch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if args[0] == "login" {
+		return c.login(ctx)
+	}
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	client, err := c.cfg.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	c.client = client
+
+	cmd, rest := args[0]+" "+args[1], args[2:]
+	switch cmd {
+	case "repo list":
+		return c.repoList(ctx, rest)
+	case "repo get":
+		return c.repoGet(ctx, rest)
+	case "repo create":
+		return c.repoCreate(ctx, rest)
+	case "repo edit":
+		return c.repoEdit(ctx, rest)
+	case "repo privacy":
+		return c.repoPrivacy(ctx, rest)
+	case "tag list":
+		return c.tagList(ctx, rest)
+	case "webhook create":
+		return c.webhookCreate(ctx, rest)
+	case "webhook list":
+		return c.webhookList(ctx, rest)
+	case "webhook delete":
+		return c.webhookDelete(ctx, rest)
+	case "org create":
+		return c.orgCreate(ctx, rest)
+	case "org list":
+		return c.orgList(ctx, rest)
+	}
+	return errUsage
+}
+
+// parseFlags parses the flags of a command and checks the number of
+// positional arguments left.
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	if fs.NArg() != nargs {
+		return nil, errUsage
+	}
+	return fs.Args(), nil
+}
+
+// splitRepo splits a namespace/repo argument.
+func splitRepo(s string) (namespace, repo string, err error) {
+	namespace, repo, ok := strings.Cut(s, "/")
+	if !ok || namespace == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("invalid repository %q; want namespace/repo", s)
+	}
+	return namespace, repo, nil
+}
+
+func (c *cli) login(ctx context.Context) error {
+	if c.cfg.Username == "" || c.cfg.Password == "" {
+		return fmt.Errorf("set %s and %s to log in", envUsername, envPassword)
+	}
+
+	client, err := (&config{Username: c.cfg.Username, Password: c.cfg.Password, APIURL: c.cfg.APIURL}).newClient(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := client.User.GetLoggedInUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Only the token is saved, so the password never touches the disk.
+	saved, err := readConfigFile(c.configPath)
+	if err != nil {
+		return err
+	}
+	saved.Username = c.cfg.Username
+	saved.Password = ""
+	saved.Token = client.AuthToken()
+	if err := saved.save(c.configPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Logged in as %s\n", user.Username)
+	return nil
+}
+
+func (c *cli) repoList(ctx context.Context, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("repo list", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	list, err := c.client.Repositories.GetRepositories(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(list.Results))
+	for _, r := range list.Results {
+		rows = append(rows, []string{r.Namespace + "/" + r.Name, strconv.FormatBool(r.IsPrivate), strconv.Itoa(r.StarCount), strconv.Itoa(r.PullCount), r.LastUpdated})
+	}
+	return c.out.print(list, []string{"NAME", "PRIVATE", "STARS", "PULLS", "LAST UPDATED"}, rows)
+}
+
+func (c *cli) printRepo(repo *dockerhub.Repository) error {
+	return c.out.print(repo, []string{"NAME", "PRIVATE", "STARS", "PULLS", "DESCRIPTION"}, [][]string{
+		{repo.Namespace + "/" + repo.Name, strconv.FormatBool(repo.IsPrivate), strconv.Itoa(repo.StarCount), strconv.Itoa(repo.PullCount), repo.Description},
+	})
+}
+
+func (c *cli) repoGet(ctx context.Context, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("repo get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	repo, err := c.client.Repositories.GetRepository(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	return c.printRepo(repo)
+}
+
+func (c *cli) repoCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("repo create", flag.ContinueOnError)
+	description := fs.String("description", "", "short description")
+	private := fs.Bool("private", false, "create a private repository")
+	args, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	repo, err := c.client.Repositories.CreateRepository(ctx, namespace, name, *description, *private)
+	if err != nil {
+		return err
+	}
+	return c.printRepo(repo)
+}
+
+func (c *cli) repoEdit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("repo edit", flag.ContinueOnError)
+	description := fs.String("description", "", "short description")
+	readme := fs.String("readme", "", "Markdown file to use as the full description")
+	args, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	patch := &dockerhub.RepositoryPatch{Description: *description}
+	if *readme != "" {
+		b, err := os.ReadFile(*readme)
+		if err != nil {
+			return err
+		}
+		patch.FullDescription = string(b)
+	}
+	if patch.Description == "" && patch.FullDescription == "" {
+		return errUsage
+	}
+
+	repo, err := c.client.Repositories.EditRepository(ctx, namespace, name, patch)
+	if err != nil {
+		return err
+	}
+	return c.printRepo(repo)
+}
+
+func (c *cli) repoPrivacy(ctx context.Context, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("repo privacy", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	var private bool
+	switch args[1] {
+	case "private":
+		private = true
+	case "public":
+	default:
+		return errUsage
+	}
+	return c.client.Repositories.SetRepositoryPrivacy(ctx, namespace, name, private)
+}
+
+func (c *cli) tagList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tag list", flag.ContinueOnError)
+	pageSize := fs.Int("n", 25, "page size")
+	args, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	tags, err := c.client.Tag.GetTags(ctx, namespace, name, *pageSize)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(tags.Results))
+	for _, t := range tags.Results {
+		platforms := make([]string, 0, len(t.Images))
+		for _, img := range t.Images {
+			platforms = append(platforms, img.Os+"/"+img.Architecture)
+		}
+		rows = append(rows, []string{t.Name, strconv.Itoa(t.FullSize), strings.Join(platforms, ","), t.LastUpdated.Format("2006-01-02 15:04:05")})
+	}
+	return c.out.print(tags, []string{"TAG", "SIZE", "PLATFORMS", "LAST UPDATED"}, rows)
+}
+
+func (c *cli) webhookCreate(ctx context.Context, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("webhook create", flag.ContinueOnError), args, 3)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	hook, err := c.client.Webhook.CreateWebhook(ctx, namespace, name, args[1], args[2])
+	if err != nil {
+		return err
+	}
+	return c.out.print(hook, []string{"NAME", "URL"}, [][]string{{args[1], args[2]}})
+}
+
+func (c *cli) webhookList(ctx context.Context, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("webhook list", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+
+	hooks, err := c.client.Webhook.GetWebhooks(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, p := range hooks.Results {
+		for _, h := range p.Webhooks {
+			rows = append(rows, []string{p.Slug, p.Name, h.HookURL})
+		}
+	}
+	return c.out.print(hooks, []string{"SLUG", "NAME", "URL"}, rows)
+}
+
+func (c *cli) webhookDelete(ctx context.Context, args []string) error {
+	args, err := parseFlags(flag.NewFlagSet("webhook delete", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	namespace, name, err := splitRepo(args[0])
+	if err != nil {
+		return err
+	}
+	return c.client.Webhook.DeleteWebhook(ctx, namespace, name, args[1])
+}
+
+func (c *cli) orgCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("org create", flag.ContinueOnError)
+	company := fs.String("company", "", "company name")
+	args, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	org, err := c.client.Organization.CreateOrganization(ctx, args[0], *company)
+	if err != nil {
+		return err
+	}
+	return c.out.print(org, []string{"NAME", "COMPANY"}, [][]string{{org.Orgname, org.Company}})
+}
+
+func (c *cli) orgList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("org list", flag.ContinueOnError)
+	pageSize := fs.Int("n", 25, "page size")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	orgs, err := c.client.Organization.GetOrganizations(ctx, *pageSize)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(orgs.Results))
+	for _, o := range orgs.Results {
+		rows = append(rows, []string{o.Orgname, o.FullName, o.Company})
+	}
+	return c.out.print(orgs, []string{"NAME", "FULL NAME", "COMPANY"}, rows)
+}
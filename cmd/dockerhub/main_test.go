@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// makeMockHub spins up a local server for the Dockerhub API and points
+// the CLI at it through the environment.
+func makeMockHub(t *testing.T) (mux *http.ServeMux, configPath string) {
+	mux = http.NewServeMux()
+	handler := http.NewServeMux()
+	handler.Handle("/v2/", http.StripPrefix("/v2", mux))
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	configPath = filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(envAPIURL, srv.URL)
+	t.Setenv(envConfig, configPath)
+	t.Setenv(envToken, "")
+	t.Setenv(envUsername, "")
+	t.Setenv(envPassword, "")
+	return mux, configPath
+}
+
+func TestRun_RepoList(t *testing.T) {
+	mux, _ := makeMockHub(t)
+	t.Setenv(envToken, "bogus")
+
+	list := &dockerhub.RepositoryList{
+		Count: 1,
+		Results: []dockerhub.Repository{
+			{Namespace: "someone", Name: "app", StarCount: 3, PullCount: 42, LastUpdated: "2023-01-02"},
+		},
+	}
+	mux.HandleFunc("/repositories/someone/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "JWT bogus" {
+			t.Errorf("Authorization is %q; want JWT bogus", got)
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"repo", "list", "someone"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code is %d; stderr: %s", code, stderr.String())
+	}
+
+	want := "NAME         PRIVATE  STARS  PULLS  LAST UPDATED\nsomeone/app  false    3      42     2023-01-02\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("output is\n%s\nwant\n%s", got, want)
+	}
+
+	stdout.Reset()
+	if code := run(context.Background(), []string{"-o", "json", "repo", "list", "someone"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code is %d; stderr: %s", code, stderr.String())
+	}
+
+	got := &dockerhub.RepositoryList{}
+	if err := json.Unmarshal(stdout.Bytes(), got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if got.Results[0].PullCount != 42 {
+		t.Errorf("JSON output is %v; want %v", got, list)
+	}
+}
+
+func TestRun_Login(t *testing.T) {
+	mux, configPath := makeMockHub(t)
+	t.Setenv(envUsername, "someone")
+	t.Setenv(envPassword, "password")
+
+	mux.HandleFunc("/users/login/", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&dockerhub.LoginResponse{Token: "bogus"})
+	})
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&dockerhub.User{Username: "someone"})
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"login"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code is %d; stderr: %s", code, stderr.String())
+	}
+	if got := stdout.String(); got != "Logged in as someone\n" {
+		t.Errorf("output is %q", got)
+	}
+
+	cfg, err := readConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("reading saved config: %v", err)
+	}
+	if cfg.Token != "bogus" || cfg.Password != "" {
+		t.Errorf("saved config is %+v; want the token and no password", cfg)
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("config file mode is %o; want 600", perm)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	makeMockHub(t)
+
+	for _, args := range [][]string{
+		{},
+		{"repo"},
+		{"repo", "frobnicate"},
+		{"repo", "get"},
+		{"repo", "privacy", "someone/app", "secret"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(context.Background(), args, &stdout, &stderr); code != 2 {
+			t.Errorf("%v: exit code is %d; want 2", args, code)
+		}
+		if !strings.HasPrefix(stderr.String(), "Usage:") {
+			t.Errorf("%v: stderr is %q; want usage", args, stderr.String())
+		}
+	}
+}
+
+func TestRun_Error(t *testing.T) {
+	mux, _ := makeMockHub(t)
+	mux.HandleFunc("/repositories/someone/missing/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"repo", "get", "someone/missing"}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code is %d; want 1", code)
+	}
+	if got, want := stderr.String(), "dockerhub: request failed with status 404\n"; got != want {
+		t.Errorf("stderr is %q; want %q", got, want)
+	}
+}
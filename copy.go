@@ -0,0 +1,363 @@
+package dockerhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Statuses reported to a CopyOptions.Progress callback.
+const (
+	CopyExists   = "exists"
+	CopyMounted  = "mounted"
+	CopyUploaded = "uploaded"
+	CopyPushed   = "pushed"
+)
+
+// Reference identifies an image on Dockerhub by repository and tag or
+// digest.
+type Reference struct {
+	Namespace  string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference such as "myorg/app:1.4.0",
+// "ubuntu@sha256:..." or "docker.io/library/ubuntu:22.04". Images
+// without a namespace are official images in "library". References to
+// other registries are rejected.
+func ParseReference(s string) (Reference, error) {
+	ref := Reference{}
+	name := s
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !strings.HasPrefix(ref.Digest, "sha256:") {
+			return Reference{}, fmt.Errorf("invalid digest in reference %q", s)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		switch parts[0] {
+		case "docker.io", "index.docker.io", "registry-1.docker.io":
+			parts = parts[1:]
+		default:
+			return Reference{}, fmt.Errorf("reference %q is not on Dockerhub", s)
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		ref.Namespace, ref.Repository = "library", parts[0]
+	case 2:
+		ref.Namespace, ref.Repository = parts[0], parts[1]
+	default:
+		return Reference{}, fmt.Errorf("invalid reference %q", s)
+	}
+	if ref.Namespace == "" || ref.Repository == "" {
+		return Reference{}, fmt.Errorf("invalid reference %q", s)
+	}
+	return ref, nil
+}
+
+// Name returns the registry name of the referenced repository.
+func (r Reference) Name() string {
+	return repositoryName(r.Namespace, r.Repository)
+}
+
+// Identifier returns the digest of the reference if it has one, else its
+// tag, else "latest".
+func (r Reference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return "latest"
+}
+
+// String returns the reference in namespace/repo[:tag][@digest] form.
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// CopyProgress reports a step of a copy.
+type CopyProgress struct {
+	Digest    string
+	MediaType string
+	Size      int64
+	Status    string
+}
+
+// CopyOptions configures a copy.
+type CopyOptions struct {
+	// Progress is called after each blob and manifest is copied.
+	Progress func(CopyProgress)
+}
+
+// copier holds the state of a single Copy.
+type copier struct {
+	s        *RegistryService
+	src, dst Reference
+	scopes   []string
+	opts     CopyOptions
+}
+
+func (c *copier) report(p CopyProgress) {
+	if c.opts.Progress != nil {
+		c.opts.Progress(p)
+	}
+}
+
+// Copy copies the image src to dst without pulling it, for example to
+// promote "myorg/app-staging:sha" to "myorg/app:1.4.0". Blobs are mounted
+// from the source repository when the registry allows it and streamed
+// through the client otherwise. Indexes are copied along with every
+// manifest they list. If dst has neither tag nor digest, the tag of src
+// is used, which is "latest" if src has neither either. It returns the
+// descriptor of the manifest pushed to dst.
+func (s *RegistryService) Copy(ctx context.Context, src, dst string, opts *CopyOptions) (*Descriptor, error) {
+	ctx = withOperation(ctx, "Registry.Copy", "", "")
+	srcRef, err := ParseReference(src)
+	if err != nil {
+		return nil, err
+	}
+	dstRef, err := ParseReference(dst)
+	if err != nil {
+		return nil, err
+	}
+	if dstRef.Tag == "" && dstRef.Digest == "" {
+		dstRef.Tag = srcRef.Tag
+		if srcRef.Digest == "" {
+			dstRef.Tag = srcRef.Identifier()
+		}
+	}
+
+	c := &copier{
+		s:   s,
+		src: srcRef,
+		dst: dstRef,
+		scopes: []string{
+			repositoryScope(srcRef.Name(), "pull"),
+			repositoryScope(dstRef.Name(), "pull", "push"),
+		},
+	}
+	if srcRef.Name() == dstRef.Name() {
+		c.scopes = c.scopes[1:]
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+
+	m, err := s.GetManifest(ctx, srcRef.Namespace, srcRef.Repository, srcRef.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.copyContents(ctx, m); err != nil {
+		return nil, err
+	}
+
+	ref := dstRef.Tag
+	if dstRef.Digest != "" {
+		if dstRef.Digest != m.Digest {
+			return nil, fmt.Errorf("destination digest %s does not match source %s", dstRef.Digest, m.Digest)
+		}
+		ref = dstRef.Digest
+	}
+	if ref == "" {
+		ref = m.Digest
+	}
+	return c.putManifest(ctx, m, ref)
+}
+
+// copyContents copies everything m references: the manifests listed by
+// an index, or the config and layers of an image manifest.
+func (c *copier) copyContents(ctx context.Context, m *ManifestResponse) error {
+	if m.IsIndex() {
+		for _, d := range m.Index.Manifests {
+			child, err := c.s.GetManifest(ctx, c.src.Namespace, c.src.Repository, d.Digest)
+			if err != nil {
+				return err
+			}
+			if err := c.copyContents(ctx, child); err != nil {
+				return err
+			}
+			if _, err := c.putManifest(ctx, child, child.Digest); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	blobs := append([]Descriptor{m.Manifest.Config}, m.Manifest.Layers...)
+	for _, d := range blobs {
+		if len(d.URLs) > 0 {
+			// Foreign layers are not stored in the registry.
+			continue
+		}
+		if err := c.copyBlob(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyBlob copies a blob to the destination repository unless it is
+// already there.
+func (c *copier) copyBlob(ctx context.Context, d Descriptor) error {
+	progress := CopyProgress{Digest: d.Digest, MediaType: d.MediaType, Size: d.Size}
+
+	exists, err := c.s.blobExists(ctx, c.dst.Name(), d.Digest, c.scopes)
+	if err != nil {
+		return err
+	}
+	if exists {
+		progress.Status = CopyExists
+		c.report(progress)
+		return nil
+	}
+
+	q := url.Values{}
+	q.Set("mount", d.Digest)
+	q.Set("from", c.src.Name())
+	req, err := c.s.newRequest(http.MethodPost, c.dst.Name(), "/blobs/uploads/?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.s.do(ctx, req, c.scopes...)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusCreated {
+		progress.Status = CopyMounted
+		c.report(progress)
+		return nil
+	}
+
+	// The registry started an upload session instead of mounting.
+	if resp.Header.Get("Location") == "" {
+		return fmt.Errorf("registry started an upload of %s without a location", d.Digest)
+	}
+	location, err := req.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+	if err := c.uploadBlob(ctx, d, location); err != nil {
+		return err
+	}
+	progress.Status = CopyUploaded
+	c.report(progress)
+	return nil
+}
+
+// uploadBlob streams a blob from the source repository into the upload
+// session at location.
+func (c *copier) uploadBlob(ctx context.Context, d Descriptor, location *url.URL) error {
+	get, err := c.s.newRequest(http.MethodGet, c.src.Name(), "/blobs/"+d.Digest, nil)
+	if err != nil {
+		return err
+	}
+	blob, err := c.s.do(ctx, get, c.scopes...)
+	if err != nil {
+		return err
+	}
+	defer blob.Body.Close()
+
+	q := location.Query()
+	q.Set("digest", d.Digest)
+	location.RawQuery = q.Encode()
+
+	put, err := http.NewRequest(http.MethodPut, location.String(), blob.Body)
+	if err != nil {
+		return err
+	}
+	put.ContentLength = blob.ContentLength
+	if d.Size > 0 {
+		put.ContentLength = d.Size
+	}
+	put.Header.Set("Content-Type", "application/octet-stream")
+	put.Header.Set("User-Agent", c.s.client.UserAgent)
+
+	resp, err := c.s.do(ctx, put, c.scopes...)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// putManifest pushes m to the destination repository under ref.
+func (c *copier) putManifest(ctx context.Context, m *ManifestResponse, ref string) (*Descriptor, error) {
+	d, err := c.s.putManifest(ctx, c.dst.Name(), ref, m.MediaType, m.Raw, c.scopes)
+	if err != nil {
+		return nil, err
+	}
+	c.report(CopyProgress{Digest: d.Digest, MediaType: d.MediaType, Size: d.Size, Status: CopyPushed})
+	return d, nil
+}
+
+// blobExists reports whether the named repository has a blob.
+func (s *RegistryService) blobExists(ctx context.Context, name, digest string, scopes []string) (bool, error) {
+	req, err := s.newRequest(http.MethodHead, name, "/blobs/"+digest, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.do(ctx, req, scopes...)
+	if err != nil {
+		var errResp *ErrorResponse
+		if errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// putManifest pushes a raw manifest to the named repository under ref.
+func (s *RegistryService) putManifest(ctx context.Context, name, ref, mediaType string, raw []byte, scopes []string) (*Descriptor, error) {
+	req, err := s.newRequest(http.MethodPut, name, "/manifests/"+ref, raw)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mediaType)
+
+	resp, err := s.do(ctx, req, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	digest := digestOf(raw)
+	if got := resp.Header.Get("Docker-Content-Digest"); got != "" && got != digest {
+		return nil, fmt.Errorf("registry stored manifest as %s; want %s", got, digest)
+	}
+	return &Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(raw))}, nil
+}
+
+// PutManifest pushes a raw manifest of the given media type to a
+// repository under a tag or digest.
+func (s *RegistryService) PutManifest(ctx context.Context, namespace, repo, reference, mediaType string, raw []byte) (*Descriptor, error) {
+	ctx = withOperation(ctx, "Registry.PutManifest", namespace, repo)
+	name := repositoryName(namespace, repo)
+	return s.putManifest(ctx, name, reference, mediaType, raw, []string{repositoryScope(name, "pull", "push")})
+}
@@ -0,0 +1,159 @@
+package dockerhub
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Reference
+		err  bool
+	}{
+		{"ubuntu", Reference{Namespace: "library", Repository: "ubuntu"}, false},
+		{"myorg/app:1.4.0", Reference{Namespace: "myorg", Repository: "app", Tag: "1.4.0"}, false},
+		{"docker.io/library/ubuntu:22.04", Reference{Namespace: "library", Repository: "ubuntu", Tag: "22.04"}, false},
+		{"ubuntu:22.04@sha256:abc", Reference{Namespace: "library", Repository: "ubuntu", Tag: "22.04", Digest: "sha256:abc"}, false},
+		{"ghcr.io/someone/app:1", Reference{}, true},
+		{"localhost:5000/app", Reference{}, true},
+		{"a/b/c", Reference{}, true},
+		{"ubuntu@md5:abc", Reference{}, true},
+	} {
+		got, err := ParseReference(tc.in)
+		if (err != nil) != tc.err {
+			t.Errorf("ParseReference(%q) error is %v; want error %v", tc.in, err, tc.err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseReference(%q) is %v; want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRegistryService_Copy(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		noMount bool
+		status  string
+	}{
+		{"mount", false, CopyMounted},
+		{"upload", true, CopyUploaded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, mux, teardown := makeMockRegistry()
+			defer teardown()
+
+			reg := newFakeRegistry(mux)
+			reg.noMount = tc.noMount
+
+			src, dst := "myorg/app-staging", "myorg/app"
+			amd64 := reg.pushImage(src, "", Platform{OS: "linux", Architecture: "amd64"}, "amd64 layer")
+			arm64 := reg.pushImage(src, "", Platform{OS: "linux", Architecture: "arm64"}, "arm64 layer")
+			index := mustJSONMarshal(&Index{
+				SchemaVersion: 2,
+				MediaType:     MediaTypeOCIIndex,
+				Manifests:     []Descriptor{amd64, arm64},
+			})
+			reg.putManifest(src, "sha", MediaTypeOCIIndex, index)
+
+			// One layer is already present in the destination.
+			reg.putBlob(dst, []byte("amd64 layer"))
+
+			counts := make(map[string]int)
+			d, err := client.Registry.Copy(context.Background(), src+":sha", dst+":1.4.0", &CopyOptions{
+				Progress: func(p CopyProgress) { counts[p.Status]++ },
+			})
+			if err != nil {
+				t.Fatalf("Registry.Copy returned error: %v", err)
+			}
+
+			if got, want := d.Digest, digestOf(index); got != want {
+				t.Errorf("copied digest is %s; want %s", got, want)
+			}
+			if m, ok := reg.manifest(dst, "1.4.0"); !ok || digestOf(m.raw) != digestOf(index) {
+				t.Errorf("destination tag does not point to the copied index")
+			}
+			for _, child := range []Descriptor{amd64, arm64} {
+				if _, ok := reg.manifest(dst, child.Digest); !ok {
+					t.Errorf("manifest %s was not copied", child.Digest)
+				}
+			}
+			for digest := range reg.blobs[src] {
+				if _, ok := reg.blob(dst, digest); !ok {
+					t.Errorf("blob %s was not copied", digest)
+				}
+			}
+
+			want := map[string]int{CopyExists: 1, tc.status: 3, CopyPushed: 3}
+			if !reflect.DeepEqual(counts, want) {
+				t.Errorf("progress is %v; want %v", counts, want)
+			}
+		})
+	}
+}
+
+func TestRegistryService_Copy_DefaultsToSourceTag(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	image := reg.pushImage("myorg/app-staging", "1.4.0", Platform{OS: "linux", Architecture: "amd64"}, "layer")
+
+	if _, err := client.Registry.Copy(context.Background(), "myorg/app-staging:1.4.0", "myorg/app", nil); err != nil {
+		t.Fatalf("Registry.Copy returned error: %v", err)
+	}
+
+	if m, ok := reg.manifest("myorg/app", "1.4.0"); !ok || digestOf(m.raw) != image.Digest {
+		t.Errorf("destination tag 1.4.0 does not point to the copied manifest")
+	}
+}
+
+func TestRegistryService_Copy_DefaultsToLatest(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	image := reg.pushImage("myorg/a", "latest", Platform{OS: "linux", Architecture: "amd64"}, "layer")
+
+	if _, err := client.Registry.Copy(context.Background(), "myorg/a", "myorg/b", nil); err != nil {
+		t.Fatalf("Registry.Copy returned error: %v", err)
+	}
+
+	if m, ok := reg.manifest("myorg/b", "latest"); !ok || digestOf(m.raw) != image.Digest {
+		t.Errorf("destination tag latest does not point to the copied manifest")
+	}
+}
+
+func TestRegistryService_Copy_NoUploadLocation(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	config := []byte(`{}`)
+	manifest := mustJSONMarshal(&Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		Config:        Descriptor{MediaType: MediaTypeOCIConfig, Digest: digestOf(config), Size: int64(len(config))},
+	})
+	mux.HandleFunc("/v2/myorg/a/manifests/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeOCIManifest)
+		w.Write(manifest)
+	})
+	mux.HandleFunc("/v2/myorg/b/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	uploads := 0
+	mux.HandleFunc("/v2/myorg/b/blobs/uploads/", func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	_, err := client.Registry.Copy(context.Background(), "myorg/a", "myorg/b", nil)
+	if want := "registry started an upload of " + digestOf(config) + " without a location"; err == nil || err.Error() != want {
+		t.Errorf("Registry.Copy error is %v; want %s", err, want)
+	}
+	if uploads != 1 {
+		t.Errorf("registry got %d upload requests; want 1", uploads)
+	}
+}
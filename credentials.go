@@ -0,0 +1,371 @@
+package dockerhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DockerHubServerURL is the server URL Docker stores Dockerhub
+// credentials under.
+const DockerHubServerURL = "https://index.docker.io/v1/"
+
+// ErrCredentialsNotFound is returned by a CredentialStore which has no
+// credentials for a server.
+var ErrCredentialsNotFound = errors.New("credentials not found")
+
+// ErrIdentityToken is returned by a CredentialStore whose credentials for
+// a server are an identity token, as stored by "docker login" with some
+// single sign-on setups. Identity tokens are not passwords, and cannot be
+// used to log in.
+var ErrIdentityToken = errors.New("credentials are an identity token")
+
+// identityTokenUsername is the username credential helpers store identity
+// tokens under.
+const identityTokenUsername = "<token>"
+
+// defaultHelperTimeout is how long a credential helper may run when
+// HelperStore gives no timeout.
+const defaultHelperTimeout = 30 * time.Second
+
+// Credentials are a username and secret for a registry server. The
+// secret is a password or personal access token.
+type Credentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// A CredentialStore stores registry credentials by server URL.
+type CredentialStore interface {
+	Get(ctx context.Context, serverURL string) (*Credentials, error)
+	Store(ctx context.Context, creds *Credentials) error
+	Erase(ctx context.Context, serverURL string) error
+}
+
+// dockerHubAliases are the server URLs Docker clients have used for
+// Dockerhub, in the order they are looked up.
+var dockerHubAliases = []string{
+	DockerHubServerURL,
+	"index.docker.io",
+	"docker.io",
+	"registry-1.docker.io",
+	"https://index.docker.io/v1",
+	"https://registry-1.docker.io",
+}
+
+// serverAliases returns the keys to look up credentials for serverURL
+// under.
+func serverAliases(serverURL string) []string {
+	for _, alias := range dockerHubAliases {
+		if serverURL == alias {
+			return dockerHubAliases
+		}
+	}
+	return []string{serverURL}
+}
+
+// HelperStore is a CredentialStore backed by a docker-credential-*
+// helper program, such as docker-credential-osxkeychain, speaking the
+// get, store and erase protocol over stdin and stdout.
+type HelperStore struct {
+	// Program is the name or path of the helper executable.
+	Program string
+
+	// Timeout is how long the helper may run before it is killed, such as
+	// when it waits for a keychain to be unlocked. It defaults to 30
+	// seconds.
+	Timeout time.Duration
+}
+
+// NewHelperStore returns a store using the helper docker-credential-<name>,
+// the form in which helpers are named in Docker's config.json.
+func NewHelperStore(name string) *HelperStore {
+	return &HelperStore{Program: "docker-credential-" + name}
+}
+
+// run invokes the helper with action, writing input to its stdin. The
+// helper is killed when ctx is done or the timeout passes.
+func (h *HelperStore) run(ctx context.Context, action string, input []byte) ([]byte, error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHelperTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, h.Program, action)
+	cmd.Stdin = bytes.NewReader(input)
+	// Do not wait on processes the helper started once it is killed.
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", h.Program, action, err)
+		}
+		if runCtx.Err() != nil {
+			return nil, fmt.Errorf("%s %s: timed out after %v", h.Program, action, timeout)
+		}
+		msg := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(msg, "credentials not found") {
+			return nil, ErrCredentialsNotFound
+		}
+		if msg == "" {
+			return nil, fmt.Errorf("%s %s: %w", h.Program, action, err)
+		}
+		return nil, fmt.Errorf("%s %s: %s", h.Program, action, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Get returns the credentials the helper holds for serverURL.
+func (h *HelperStore) Get(ctx context.Context, serverURL string) (*Credentials, error) {
+	out, err := h.run(ctx, "get", []byte(serverURL))
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &Credentials{}
+	if err := json.Unmarshal(out, creds); err != nil {
+		return nil, err
+	}
+	if creds.Username == identityTokenUsername {
+		return nil, fmt.Errorf("%w for %s", ErrIdentityToken, serverURL)
+	}
+	if creds.ServerURL == "" {
+		creds.ServerURL = serverURL
+	}
+	return creds, nil
+}
+
+// Store saves creds in the helper.
+func (h *HelperStore) Store(ctx context.Context, creds *Credentials) error {
+	b, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	_, err = h.run(ctx, "store", b)
+	return err
+}
+
+// Erase removes the credentials for serverURL from the helper.
+func (h *HelperStore) Erase(ctx context.Context, serverURL string) error {
+	_, err := h.run(ctx, "erase", []byte(serverURL))
+	return err
+}
+
+// DockerConfigStore is a CredentialStore backed by a Docker config.json.
+// Credentials are kept in its auths entries, unless the file names a
+// credential helper for the server in credHelpers or a default one in
+// credsStore.
+type DockerConfigStore struct {
+	// Path is the location of config.json.
+	Path string
+}
+
+// NewDockerConfigStore returns a store for the config.json at path. An
+// empty path means the file Docker uses: config.json in $DOCKER_CONFIG,
+// or in ~/.docker.
+func NewDockerConfigStore(path string) *DockerConfigStore {
+	if path == "" {
+		dir := os.Getenv("DOCKER_CONFIG")
+		if dir == "" {
+			home, _ := os.UserHomeDir()
+			dir = filepath.Join(home, ".docker")
+		}
+		path = filepath.Join(dir, "config.json")
+	}
+	return &DockerConfigStore{Path: path}
+}
+
+// dockerAuth is an entry of the auths section of config.json.
+type dockerAuth struct {
+	Auth          string `json:"auth,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// dockerConfig is the part of config.json concerning credentials.
+type dockerConfig struct {
+	Auths       map[string]dockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+}
+
+// load reads config.json, returning both the credential settings and
+// every field so that a rewrite keeps the ones it does not understand.
+func (d *DockerConfigStore) load() (*dockerConfig, map[string]json.RawMessage, error) {
+	cfg := &dockerConfig{}
+	raw := make(map[string]json.RawMessage)
+
+	b, err := os.ReadFile(d.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, raw, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, nil, err
+	}
+	return cfg, raw, nil
+}
+
+// save writes the auths of cfg into config.json.
+func (d *DockerConfigStore) save(cfg *dockerConfig, raw map[string]json.RawMessage) error {
+	auths, err := json.Marshal(cfg.Auths)
+	if err != nil {
+		return err
+	}
+	raw["auths"] = auths
+
+	b, err := json.MarshalIndent(raw, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.Path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(d.Path, append(b, '\n'), 0o600)
+}
+
+// helper returns the credential helper configured for serverURL, if any.
+func (cfg *dockerConfig) helper(serverURL string) *HelperStore {
+	for _, alias := range serverAliases(serverURL) {
+		if name, ok := cfg.CredHelpers[alias]; ok && name != "" {
+			return NewHelperStore(name)
+		}
+	}
+	if cfg.CredsStore != "" {
+		return NewHelperStore(cfg.CredsStore)
+	}
+	return nil
+}
+
+// Get returns the credentials for serverURL, from its credential helper
+// if one is configured, else from the auths entries.
+func (d *DockerConfigStore) Get(ctx context.Context, serverURL string) (*Credentials, error) {
+	cfg, _, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if h := cfg.helper(serverURL); h != nil {
+		return h.Get(ctx, serverURL)
+	}
+
+	for _, alias := range serverAliases(serverURL) {
+		auth, ok := cfg.Auths[alias]
+		if !ok {
+			continue
+		}
+
+		creds := &Credentials{ServerURL: serverURL, Username: auth.Username, Secret: auth.Password}
+		if auth.Auth != "" {
+			b, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for %s: %w", alias, err)
+			}
+			username, password, ok := strings.Cut(string(b), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid auth for %s", alias)
+			}
+			creds.Username, creds.Secret = username, password
+		}
+		if auth.IdentityToken != "" {
+			return nil, fmt.Errorf("%w for %s", ErrIdentityToken, alias)
+		}
+		if creds.Username == "" && creds.Secret == "" {
+			continue
+		}
+		return creds, nil
+	}
+	return nil, ErrCredentialsNotFound
+}
+
+// Store saves creds in the configured credential helper, or else as an
+// auths entry of config.json.
+func (d *DockerConfigStore) Store(ctx context.Context, creds *Credentials) error {
+	cfg, raw, err := d.load()
+	if err != nil {
+		return err
+	}
+
+	if h := cfg.helper(creds.ServerURL); h != nil {
+		return h.Store(ctx, creds)
+	}
+
+	if cfg.Auths == nil {
+		cfg.Auths = make(map[string]dockerAuth)
+	}
+	cfg.Auths[creds.ServerURL] = dockerAuth{
+		Auth: base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Secret)),
+	}
+	return d.save(cfg, raw)
+}
+
+// Erase removes the credentials for serverURL from the configured
+// credential helper, or else from the auths entries of config.json.
+func (d *DockerConfigStore) Erase(ctx context.Context, serverURL string) error {
+	cfg, raw, err := d.load()
+	if err != nil {
+		return err
+	}
+
+	if h := cfg.helper(serverURL); h != nil {
+		return h.Erase(ctx, serverURL)
+	}
+
+	found := false
+	for _, alias := range serverAliases(serverURL) {
+		if _, ok := cfg.Auths[alias]; ok {
+			delete(cfg.Auths, alias)
+			found = true
+		}
+	}
+	if !found {
+		return ErrCredentialsNotFound
+	}
+	return d.save(cfg, raw)
+}
+
+// LoginWithStore logs in with the Dockerhub credentials held by store,
+// and uses them for registry requests as well. Stores holding an identity
+// token rather than a password or personal access token fail with
+// ErrIdentityToken. With a nil store, the Docker config.json of the
+// current user is used.
+func (s *AuthService) LoginWithStore(ctx context.Context, store CredentialStore) error {
+	ctx = withOperation(ctx, "Auth.LoginWithStore", "", "")
+	if store == nil {
+		store = NewDockerConfigStore("")
+	}
+
+	creds, err := store.Get(ctx, DockerHubServerURL)
+	if err != nil {
+		return err
+	}
+	if creds.Username == "" {
+		return errors.New("stored credentials have no username")
+	}
+
+	if err := s.Login(ctx, creds.Username, creds.Secret); err != nil {
+		return err
+	}
+	s.client.SetRegistryAuth(creds.Username, creds.Secret)
+	return nil
+}
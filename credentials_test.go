@@ -0,0 +1,253 @@
+package dockerhub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// fakeHelper is a docker-credential-* helper holding at most one set of
+// credentials in the file "stored" of its directory.
+const fakeHelper = `#!/bin/sh
+stored="$FAKE_HELPER_DIR/stored"
+case "$1" in
+get)
+	url=$(cat)
+	if [ -f "$stored" ] && grep -qF "\"ServerURL\":\"$url\"" "$stored"; then
+		cat "$stored"
+	else
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	;;
+store)
+	cat > "$stored"
+	;;
+erase)
+	url=$(cat)
+	if [ -f "$stored" ] && grep -qF "\"ServerURL\":\"$url\"" "$stored"; then
+		rm "$stored"
+	else
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	;;
+*)
+	echo "unknown action $1" >&2
+	exit 1
+	;;
+esac
+`
+
+// installFakeHelper puts docker-credential-fake on the PATH.
+func installFakeHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake credential helper is a shell script")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(fakeHelper), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_HELPER_DIR", dir)
+}
+
+// writeDockerConfig writes a config.json and returns a store for it.
+func writeDockerConfig(t *testing.T, cfg string) *DockerConfigStore {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return NewDockerConfigStore(path)
+}
+
+func TestHelperStore(t *testing.T) {
+	installFakeHelper(t)
+	store := NewHelperStore("fake")
+
+	if _, err := store.Get(context.Background(), DockerHubServerURL); !errors.Is(err, ErrCredentialsNotFound) {
+		t.Errorf("HelperStore.Get error is %v; want ErrCredentialsNotFound", err)
+	}
+
+	creds := &Credentials{ServerURL: DockerHubServerURL, Username: "someone", Secret: "dckr_pat_bogus"}
+	if err := store.Store(context.Background(), creds); err != nil {
+		t.Fatalf("HelperStore.Store returned error: %v", err)
+	}
+
+	got, err := store.Get(context.Background(), DockerHubServerURL)
+	if err != nil {
+		t.Fatalf("HelperStore.Get returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, creds) {
+		t.Errorf("credentials are %v; want %v", got, creds)
+	}
+
+	if err := store.Erase(context.Background(), DockerHubServerURL); err != nil {
+		t.Fatalf("HelperStore.Erase returned error: %v", err)
+	}
+	if _, err := store.Get(context.Background(), DockerHubServerURL); !errors.Is(err, ErrCredentialsNotFound) {
+		t.Errorf("HelperStore.Get after erase error is %v; want ErrCredentialsNotFound", err)
+	}
+}
+
+func TestDockerConfigStore_Auths(t *testing.T) {
+	store := writeDockerConfig(t, `{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "c29tZW9uZTpwYXNzd29yZA=="},
+		"ghcr.io": {"username": "other", "password": "secret"}
+	},
+	"currentContext": "default"
+}`)
+
+	got, err := store.Get(context.Background(), DockerHubServerURL)
+	if err != nil {
+		t.Fatalf("DockerConfigStore.Get returned error: %v", err)
+	}
+	want := &Credentials{ServerURL: DockerHubServerURL, Username: "someone", Secret: "password"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("credentials are %v; want %v", got, want)
+	}
+
+	// Dockerhub credentials are found under any of its aliases.
+	if got, err := store.Get(context.Background(), "docker.io"); err != nil || got.Username != "someone" {
+		t.Errorf("DockerConfigStore.Get(docker.io) is %v, %v; want someone", got, err)
+	}
+
+	if err := store.Store(context.Background(), &Credentials{ServerURL: "quay.io", Username: "q", Secret: "s"}); err != nil {
+		t.Fatalf("DockerConfigStore.Store returned error: %v", err)
+	}
+	if err := store.Erase(context.Background(), DockerHubServerURL); err != nil {
+		t.Fatalf("DockerConfigStore.Erase returned error: %v", err)
+	}
+
+	b, err := os.ReadFile(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := map[string]interface{}{}
+	if err := json.Unmarshal(b, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved["currentContext"] != "default" {
+		t.Errorf("rewrite dropped currentContext: %s", b)
+	}
+	auths := saved["auths"].(map[string]interface{})
+	if _, ok := auths[DockerHubServerURL]; ok {
+		t.Errorf("erased credentials still present: %s", b)
+	}
+	if _, ok := auths["quay.io"]; !ok {
+		t.Errorf("stored credentials missing: %s", b)
+	}
+}
+
+func TestDockerConfigStore_Helpers(t *testing.T) {
+	installFakeHelper(t)
+
+	store := writeDockerConfig(t, `{"credsStore": "missing", "credHelpers": {"index.docker.io": "fake"}}`)
+	creds := &Credentials{ServerURL: DockerHubServerURL, Username: "someone", Secret: "password"}
+	if err := store.Store(context.Background(), creds); err != nil {
+		t.Fatalf("DockerConfigStore.Store returned error: %v", err)
+	}
+
+	got, err := store.Get(context.Background(), DockerHubServerURL)
+	if err != nil {
+		t.Fatalf("DockerConfigStore.Get returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, creds) {
+		t.Errorf("credentials are %v; want %v", got, creds)
+	}
+}
+
+func TestAuthService_LoginWithStore(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	store := writeDockerConfig(t, `{"auths": {"https://index.docker.io/v1/": {"auth": "c29tZW9uZTpwYXNzd29yZA=="}}}`)
+
+	mux.HandleFunc("/users/login/", func(w http.ResponseWriter, r *http.Request) {
+		assertBody(t, r, string(mustJSONMarshal(&LoginRequest{Username: "someone", Password: "password"})))
+		w.Write(mustJSONMarshal(&LoginResponse{Token: "bogus"}))
+	})
+
+	if err := client.Auth.LoginWithStore(context.Background(), store); err != nil {
+		t.Fatalf("Auth.LoginWithStore returned error: %v", err)
+	}
+
+	if got := client.authToken; got != "bogus" {
+		t.Errorf("client.authToken is %s; want bogus", got)
+	}
+	if client.registryUsername != "someone" || client.registryPassword != "password" {
+		t.Errorf("registry auth is %s:%s; want someone:password", client.registryUsername, client.registryPassword)
+	}
+}
+
+func TestHelperStore_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hanging credential helper is a shell script")
+	}
+
+	program := filepath.Join(t.TempDir(), "docker-credential-hang")
+	if err := os.WriteFile(program, []byte("#!/bin/sh\nexec sleep 60\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	store := &HelperStore{Program: program, Timeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	_, err := store.Get(context.Background(), DockerHubServerURL)
+	if want := program + " get: timed out after 50ms"; err == nil || err.Error() != want {
+		t.Errorf("HelperStore.Get error is %v; want %s", err, want)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("HelperStore.Get took %v to time out", elapsed)
+	}
+}
+
+func TestAuthService_LoginWithStore_Canceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hanging credential helper is a shell script")
+	}
+
+	client, _, teardown := makeMockClient()
+	defer teardown()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker-credential-hang"), []byte("#!/bin/sh\nexec sleep 60\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	store := writeDockerConfig(t, `{"credsStore": "hang"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.Auth.LoginWithStore(ctx, store); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Auth.LoginWithStore returned %v; want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Auth.LoginWithStore took %v to return", elapsed)
+	}
+}
+
+func TestAuthService_LoginWithStore_IdentityToken(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	store := writeDockerConfig(t, `{"auths": {"https://index.docker.io/v1/": {"auth": "c29tZW9uZTo=", "identitytoken": "refresh"}}}`)
+
+	mux.HandleFunc("/users/login/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected login with an identity token")
+	})
+
+	if err := client.Auth.LoginWithStore(context.Background(), store); !errors.Is(err, ErrIdentityToken) {
+		t.Errorf("Auth.LoginWithStore returned %v; want ErrIdentityToken", err)
+	}
+}
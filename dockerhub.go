@@ -6,14 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 )
 
 const (
 	defaultUserAgent       = "dockerhub-go/v1"
 	defaultAPIBaseURL      = "https://hub.docker.com"
 	defaultAPIBaseEndpoint = "/v2"
+
+	defaultRegistryURL     = "https://registry-1.docker.io"
+	defaultRegistryAuthURL = "https://auth.docker.io/token"
+	defaultRegistryService = "registry.docker.io"
 )
 
 // A Client manages communication with the Dockerhub API.
@@ -22,8 +29,43 @@ type Client struct {
 	BaseURL    *url.URL
 	UserAgent  string
 
+	// RegistryURL and RegistryAuthURL locate the registry API used by
+	// the RegistryService and the token server which authorizes it.
+	RegistryURL     *url.URL
+	RegistryAuthURL *url.URL
+
+	// Logger, if set, receives a record of every request the client
+	// sends, with its status, latency and rate limit headers.
+	Logger *slog.Logger
+
+	// LogBodies adds request and response bodies to the records sent to
+	// Logger, with passwords and tokens redacted.
+	LogBodies bool
+
+	// RateLimiter, if set, paces the requests of the client.
+	RateLimiter *RateLimiter
+
+	// CircuitBreaker, if set, fails requests fast while Dockerhub is
+	// unavailable.
+	CircuitBreaker *CircuitBreaker
+
+	middleware []Middleware
+
+	rateMu      sync.Mutex
+	pausedUntil time.Time
+
+	// now and sleep tell the time and wait out a rate limit pause. Tests
+	// replace them so as not to sleep.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
 	authToken string
 
+	registryUsername string
+	registryPassword string
+	registryTokens   map[string]registryToken
+	registryMu       sync.Mutex
+
 	common service
 
 	Auth         *AuthService
@@ -32,6 +74,8 @@ type Client struct {
 	Webhook      *WebhookService
 	Organization *OrganizationService
 	Tag          *TagService
+	AccessTokens *AccessTokenService
+	Registry     *RegistryService
 }
 
 // NewClient returns a new Dockerhub client. If an httpClient is not
@@ -42,11 +86,18 @@ func NewClient(httpClient *http.Client) *Client {
 	}
 
 	baseURL, _ := url.Parse(defaultAPIBaseURL)
+	registryURL, _ := url.Parse(defaultRegistryURL)
+	registryAuthURL, _ := url.Parse(defaultRegistryAuthURL)
 
 	c := &Client{
-		httpClient: httpClient,
-		UserAgent:  defaultUserAgent,
-		BaseURL:    baseURL,
+		httpClient:      httpClient,
+		UserAgent:       defaultUserAgent,
+		BaseURL:         baseURL,
+		RegistryURL:     registryURL,
+		RegistryAuthURL: registryAuthURL,
+		registryTokens:  make(map[string]registryToken),
+		now:             time.Now,
+		sleep:           sleep,
 	}
 	c.common.client = c
 	c.Auth = (*AuthService)(&c.common)
@@ -55,6 +106,8 @@ func NewClient(httpClient *http.Client) *Client {
 	c.Webhook = (*WebhookService)(&c.common)
 	c.Organization = (*OrganizationService)(&c.common)
 	c.Tag = (*TagService)(&c.common)
+	c.AccessTokens = (*AccessTokenService)(&c.common)
+	c.Registry = (*RegistryService)(&c.common)
 	return c
 }
 
@@ -68,11 +121,61 @@ func (c *Client) SetAuthToken(token string) {
 	c.authToken = token
 }
 
+// AuthToken returns the Authorization token sent with API requests, as set
+// by SetAuthToken or obtained by logging in.
+func (c *Client) AuthToken() string {
+	return c.authToken
+}
+
+// SetRegistryAuth sets the credentials exchanged for registry bearer
+// tokens. Without them, the RegistryService requests anonymous tokens.
+func (c *Client) SetRegistryAuth(username, password string) {
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+
+	c.registryUsername = username
+	c.registryPassword = password
+	c.registryTokens = make(map[string]registryToken)
+}
+
 // Do sends an API request and returns the API response. The API response is JSON
 // decoded and stored in the value pointed to by v.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send sends a request through the middleware without interpreting the
+// response.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
 	req = req.WithContext(ctx)
-	resp, err := c.httpClient.Do(req)
+	if waitsForRateLimit(ctx) {
+		if err := c.waitRateLimit(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var reqBody []byte
+	if c.Logger != nil && c.LogBodies {
+		reqBody = peekRequestBody(req)
+	}
+	start := time.Now()
+	resp, err := c.doer().Do(req)
+	if c.Logger != nil {
+		c.logRequest(ctx, req, reqBody, resp, err, time.Since(start))
+	}
 	if err != nil {
 		select {
 		case <-ctx.Done():
@@ -82,16 +185,41 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if err := checkResponse(resp); err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.pauseUntil(rateLimitReset(resp, c.now()))
 	}
+	return resp, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(v); err != nil && err != io.EOF {
-		return nil, err
+// roundTrip sends a request which got past the middleware to the API.
+// Pacing and the circuit breaker apply here rather than in send, so that
+// responses served by middleware such as CacheMiddleware neither use up
+// the rate limit nor report a stale budget, and the breaker only counts
+// requests which were actually sent.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	if c.RateLimiter != nil {
+		if err := c.RateLimiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
 	}
-	return resp, nil
+
+	var generation uint64
+	if c.CircuitBreaker != nil {
+		var err error
+		if generation, err = c.CircuitBreaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := c.httpClient.Do(req)
+	if c.CircuitBreaker != nil {
+		c.CircuitBreaker.record(generation, resp, err)
+	}
+
+	if err == nil && c.RateLimiter != nil {
+		c.RateLimiter.observe(resp, time.Now())
+	}
+	return resp, err
 }
 
 // NewRequest creates an API request. The given URL is relative to the Client's
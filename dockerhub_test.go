@@ -88,3 +88,39 @@ func mustJSONMarshal(v interface{}) []byte {
 	}
 	return buf.Bytes()
 }
+
+// mockRegistryToken is the only bearer token accepted by the registry
+// spun up by makeMockRegistry.
+const mockRegistryToken = "registry-token"
+
+// makeMockRegistry spins up a local registry and token server and returns
+// a Client pointing to it. Registry handlers should be added to mux under
+// /v2/; requests reach them only with a token issued by /token, otherwise
+// they are challenged.
+func makeMockRegistry() (client *Client, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write(mustJSONMarshal(map[string]interface{}{
+				"token":      mockRegistryToken,
+				"expires_in": 300,
+			}))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+mockRegistryToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="mock-registry"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+
+	client = NewClient(nil)
+	client.RegistryURL, _ = url.Parse(srv.URL)
+	client.RegistryAuthURL, _ = url.Parse(srv.URL + "/token")
+
+	return client, mux, srv.Close
+}
@@ -0,0 +1,269 @@
+// Package dockerhubmock provides mocks of the dockerhub service
+// interfaces.
+//
+// Each mock has a field per method, named after it with a Func suffix,
+// which is called in its place. Calling a method whose field is unset
+// returns ErrNotImplemented:
+//
+//	tags := &dockerhubmock.Tags{
+//		GetTagFunc: func(ctx context.Context, namespace, repo, tag string) (*dockerhub.Tag, error) {
+//			return &dockerhub.Tag{Name: tag}, nil
+//		},
+//	}
+package dockerhubmock
+
+import (
+	"context"
+	"errors"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// ErrNotImplemented is returned by a mock method with no function set.
+var ErrNotImplemented = errors.New("dockerhubmock: method not implemented")
+
+var (
+	_ dockerhub.RepositoriesAPI  = (*Repositories)(nil)
+	_ dockerhub.TagsAPI          = (*Tags)(nil)
+	_ dockerhub.WebhooksAPI      = (*Webhooks)(nil)
+	_ dockerhub.OrganizationsAPI = (*Organizations)(nil)
+	_ dockerhub.UserAPI          = (*User)(nil)
+	_ dockerhub.AuthAPI          = (*Auth)(nil)
+	_ dockerhub.AccessTokensAPI  = (*AccessTokens)(nil)
+)
+
+// Repositories is a mock of dockerhub.RepositoriesAPI.
+type Repositories struct {
+	CreateRepositoryFunc     func(ctx context.Context, namespace, name, description string, isPrivate bool) (*dockerhub.Repository, error)
+	EditRepositoryFunc       func(ctx context.Context, namespace, repo string, patch *dockerhub.RepositoryPatch) (*dockerhub.Repository, error)
+	GetRepositoryFunc        func(ctx context.Context, namespace, repo string) (*dockerhub.Repository, error)
+	SetRepositoryPrivacyFunc func(ctx context.Context, namespace, repo string, isPrivate bool) error
+	GetRepositoriesFunc      func(ctx context.Context, namespace string) (*dockerhub.RepositoryList, error)
+	SyncReadmeFunc           func(ctx context.Context, namespace, repo, filename string, opts *dockerhub.SyncReadmeOptions) (bool, error)
+}
+
+func (m *Repositories) CreateRepository(ctx context.Context, namespace, name, description string, isPrivate bool) (*dockerhub.Repository, error) {
+	if m.CreateRepositoryFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.CreateRepositoryFunc(ctx, namespace, name, description, isPrivate)
+}
+
+func (m *Repositories) EditRepository(ctx context.Context, namespace, repo string, patch *dockerhub.RepositoryPatch) (*dockerhub.Repository, error) {
+	if m.EditRepositoryFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.EditRepositoryFunc(ctx, namespace, repo, patch)
+}
+
+func (m *Repositories) GetRepository(ctx context.Context, namespace, repo string) (*dockerhub.Repository, error) {
+	if m.GetRepositoryFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetRepositoryFunc(ctx, namespace, repo)
+}
+
+func (m *Repositories) SetRepositoryPrivacy(ctx context.Context, namespace, repo string, isPrivate bool) error {
+	if m.SetRepositoryPrivacyFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.SetRepositoryPrivacyFunc(ctx, namespace, repo, isPrivate)
+}
+
+func (m *Repositories) GetRepositories(ctx context.Context, namespace string) (*dockerhub.RepositoryList, error) {
+	if m.GetRepositoriesFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetRepositoriesFunc(ctx, namespace)
+}
+
+func (m *Repositories) SyncReadme(ctx context.Context, namespace, repo, filename string, opts *dockerhub.SyncReadmeOptions) (bool, error) {
+	if m.SyncReadmeFunc == nil {
+		return false, ErrNotImplemented
+	}
+	return m.SyncReadmeFunc(ctx, namespace, repo, filename, opts)
+}
+
+// Tags is a mock of dockerhub.TagsAPI.
+type Tags struct {
+	GetTagsFunc func(ctx context.Context, namespace, repo string, page int) (*dockerhub.Tags, error)
+	GetTagFunc  func(ctx context.Context, namespace, repo, tag string) (*dockerhub.Tag, error)
+}
+
+func (m *Tags) GetTags(ctx context.Context, namespace, repo string, page int) (*dockerhub.Tags, error) {
+	if m.GetTagsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetTagsFunc(ctx, namespace, repo, page)
+}
+
+func (m *Tags) GetTag(ctx context.Context, namespace, repo, tag string) (*dockerhub.Tag, error) {
+	if m.GetTagFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetTagFunc(ctx, namespace, repo, tag)
+}
+
+// Webhooks is a mock of dockerhub.WebhooksAPI.
+type Webhooks struct {
+	CreateWebhookFunc func(ctx context.Context, namespace, repo, name, url string) (*dockerhub.WebhookResponse, error)
+	GetWebhooksFunc   func(ctx context.Context, namespace, repo string) (*dockerhub.WebhookResponse, error)
+	DeleteWebhookFunc func(ctx context.Context, namespace, repo, name string) error
+	GetWebhookFunc    func(ctx context.Context, namespace, repo, slug string) (*dockerhub.Results, error)
+	UpdateWebhookFunc func(ctx context.Context, namespace, repo, slug string, hook *dockerhub.WebhookRequest) (*dockerhub.Results, error)
+}
+
+func (m *Webhooks) CreateWebhook(ctx context.Context, namespace, repo, name, url string) (*dockerhub.WebhookResponse, error) {
+	if m.CreateWebhookFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.CreateWebhookFunc(ctx, namespace, repo, name, url)
+}
+
+func (m *Webhooks) GetWebhooks(ctx context.Context, namespace, repo string) (*dockerhub.WebhookResponse, error) {
+	if m.GetWebhooksFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetWebhooksFunc(ctx, namespace, repo)
+}
+
+func (m *Webhooks) DeleteWebhook(ctx context.Context, namespace, repo, name string) error {
+	if m.DeleteWebhookFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.DeleteWebhookFunc(ctx, namespace, repo, name)
+}
+
+func (m *Webhooks) GetWebhook(ctx context.Context, namespace, repo, slug string) (*dockerhub.Results, error) {
+	if m.GetWebhookFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetWebhookFunc(ctx, namespace, repo, slug)
+}
+
+func (m *Webhooks) UpdateWebhook(ctx context.Context, namespace, repo, slug string, hook *dockerhub.WebhookRequest) (*dockerhub.Results, error) {
+	if m.UpdateWebhookFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.UpdateWebhookFunc(ctx, namespace, repo, slug, hook)
+}
+
+// Organizations is a mock of dockerhub.OrganizationsAPI.
+type Organizations struct {
+	CreateOrganizationFunc func(ctx context.Context, organization, company string) (*dockerhub.Organization, error)
+	GetOrganizationsFunc   func(ctx context.Context, pageSize int) (*dockerhub.OrganizationList, error)
+}
+
+func (m *Organizations) CreateOrganization(ctx context.Context, organization, company string) (*dockerhub.Organization, error) {
+	if m.CreateOrganizationFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.CreateOrganizationFunc(ctx, organization, company)
+}
+
+func (m *Organizations) GetOrganizations(ctx context.Context, pageSize int) (*dockerhub.OrganizationList, error) {
+	if m.GetOrganizationsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetOrganizationsFunc(ctx, pageSize)
+}
+
+// User is a mock of dockerhub.UserAPI.
+type User struct {
+	GetLoggedInUserFunc func(ctx context.Context) (*dockerhub.User, error)
+}
+
+func (m *User) GetLoggedInUser(ctx context.Context) (*dockerhub.User, error) {
+	if m.GetLoggedInUserFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetLoggedInUserFunc(ctx)
+}
+
+// Auth is a mock of dockerhub.AuthAPI.
+type Auth struct {
+	LoginFunc          func(ctx context.Context, username, password string) error
+	LoginWithStoreFunc func(ctx context.Context, store dockerhub.CredentialStore) error
+}
+
+func (m *Auth) Login(ctx context.Context, username, password string) error {
+	if m.LoginFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.LoginFunc(ctx, username, password)
+}
+
+func (m *Auth) LoginWithStore(ctx context.Context, store dockerhub.CredentialStore) error {
+	if m.LoginWithStoreFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.LoginWithStoreFunc(ctx, store)
+}
+
+// AccessTokens is a mock of dockerhub.AccessTokensAPI.
+type AccessTokens struct {
+	CreateAccessTokenFunc    func(ctx context.Context, label string, scopes []string) (*dockerhub.AccessToken, error)
+	GetAccessTokensFunc      func(ctx context.Context, page, pageSize int) (*dockerhub.AccessTokenList, error)
+	GetAccessTokenFunc       func(ctx context.Context, uuid string) (*dockerhub.AccessToken, error)
+	UpdateAccessTokenFunc    func(ctx context.Context, uuid string, patch *dockerhub.AccessTokenPatch) (*dockerhub.AccessToken, error)
+	SetAccessTokenActiveFunc func(ctx context.Context, uuid string, isActive bool) (*dockerhub.AccessToken, error)
+	RenameAccessTokenFunc    func(ctx context.Context, uuid, label string) (*dockerhub.AccessToken, error)
+	DeleteAccessTokenFunc    func(ctx context.Context, uuid string) error
+	RotateTokenFunc          func(ctx context.Context, uuid string, verify func(context.Context, *dockerhub.AccessToken) error) (*dockerhub.AccessToken, error)
+}
+
+func (m *AccessTokens) CreateAccessToken(ctx context.Context, label string, scopes []string) (*dockerhub.AccessToken, error) {
+	if m.CreateAccessTokenFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.CreateAccessTokenFunc(ctx, label, scopes)
+}
+
+func (m *AccessTokens) GetAccessTokens(ctx context.Context, page, pageSize int) (*dockerhub.AccessTokenList, error) {
+	if m.GetAccessTokensFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetAccessTokensFunc(ctx, page, pageSize)
+}
+
+func (m *AccessTokens) GetAccessToken(ctx context.Context, uuid string) (*dockerhub.AccessToken, error) {
+	if m.GetAccessTokenFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetAccessTokenFunc(ctx, uuid)
+}
+
+func (m *AccessTokens) UpdateAccessToken(ctx context.Context, uuid string, patch *dockerhub.AccessTokenPatch) (*dockerhub.AccessToken, error) {
+	if m.UpdateAccessTokenFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.UpdateAccessTokenFunc(ctx, uuid, patch)
+}
+
+func (m *AccessTokens) SetAccessTokenActive(ctx context.Context, uuid string, isActive bool) (*dockerhub.AccessToken, error) {
+	if m.SetAccessTokenActiveFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.SetAccessTokenActiveFunc(ctx, uuid, isActive)
+}
+
+func (m *AccessTokens) RenameAccessToken(ctx context.Context, uuid, label string) (*dockerhub.AccessToken, error) {
+	if m.RenameAccessTokenFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.RenameAccessTokenFunc(ctx, uuid, label)
+}
+
+func (m *AccessTokens) DeleteAccessToken(ctx context.Context, uuid string) error {
+	if m.DeleteAccessTokenFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.DeleteAccessTokenFunc(ctx, uuid)
+}
+
+func (m *AccessTokens) RotateToken(ctx context.Context, uuid string, verify func(context.Context, *dockerhub.AccessToken) error) (*dockerhub.AccessToken, error) {
+	if m.RotateTokenFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.RotateTokenFunc(ctx, uuid, verify)
+}
@@ -0,0 +1,45 @@
+package dockerhubmock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// latestTag is code under test which depends on the tags interface.
+func latestTag(ctx context.Context, tags dockerhub.TagsAPI, namespace, repo string) (string, error) {
+	res, err := tags.GetTags(ctx, namespace, repo, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(res.Results) == 0 {
+		return "", errors.New("no tags")
+	}
+	return res.Results[0].Name, nil
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	tags := &Tags{
+		GetTagsFunc: func(ctx context.Context, namespace, repo string, page int) (*dockerhub.Tags, error) {
+			if namespace != "someone" || repo != "app" {
+				t.Errorf("GetTags called for %s/%s", namespace, repo)
+			}
+			return &dockerhub.Tags{Count: 1, Results: []dockerhub.Tag{{Name: "1.4.0"}}}, nil
+		},
+	}
+
+	got, err := latestTag(ctx, tags, "someone", "app")
+	if err != nil {
+		t.Fatalf("latestTag returned error: %v", err)
+	}
+	if got != "1.4.0" {
+		t.Errorf("latestTag returned %q; want 1.4.0", got)
+	}
+
+	if _, err := tags.GetTag(ctx, "someone", "app", "1.4.0"); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("GetTag without GetTagFunc returned %v; want ErrNotImplemented", err)
+	}
+}
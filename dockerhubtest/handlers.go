@@ -0,0 +1,505 @@
+package dockerhubtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	h := randomHex(16)
+	return fmt.Sprintf("%s-%s-4%s-a%s-%s", h[0:8], h[8:12], h[13:16], h[17:20], h[20:32])
+}
+
+var slugRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify returns the slug Dockerhub derives from a webhook name.
+func slugify(name string) string {
+	return strings.Trim(slugRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// decode reads the JSON request body into v, answering 400 on failure.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	req := &dockerhub.LoginRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+
+	a, ok := s.accounts[req.Username]
+	valid := ok && req.Password == a.password
+	if ok && !valid {
+		// Personal access tokens are accepted in place of the password.
+		for _, t := range s.accessTokens {
+			if t.owner == req.Username && t.token.IsActive && t.token.Token == req.Password {
+				valid = true
+				t.token.LastUsed = s.Now().UTC()
+			}
+		}
+	}
+	if !valid {
+		writeError(w, http.StatusUnauthorized, "incorrect authentication credentials")
+		return
+	}
+
+	token := randomHex(32)
+	s.sessions[token] = req.Username
+	writeJSON(w, http.StatusOK, &dockerhub.LoginResponse{Token: token})
+}
+
+func (s *Server) createOrganization(w http.ResponseWriter, r *http.Request, user string) {
+	req := &dockerhub.CreateOrganizationRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.Orgname == "" {
+		writeError(w, http.StatusBadRequest, "orgname is required")
+		return
+	}
+	if _, ok := s.orgs[req.Orgname]; ok {
+		writeError(w, http.StatusConflict, "namespace is taken")
+		return
+	}
+	if _, ok := s.accounts[req.Orgname]; ok {
+		writeError(w, http.StatusConflict, "namespace is taken")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.addOrganization(user, req.Orgname, req.Company))
+}
+
+func (s *Server) listOrganizations(w http.ResponseWriter, r *http.Request, user string) {
+	var orgs []dockerhub.Organization
+	for _, o := range s.orgs {
+		if o.owners[user] {
+			orgs = append(orgs, o.org)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].Orgname < orgs[j].Orgname })
+
+	p, start, end, err := paginate(r, len(orgs))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	p.Results = append([]dockerhub.Organization{}, orgs[start:end]...)
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) createRepository(w http.ResponseWriter, r *http.Request, user string) {
+	req := &dockerhub.CreateRepositoryRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.Namespace == "" || req.Name == "" {
+		writeError(w, http.StatusBadRequest, "namespace and name are required")
+		return
+	}
+	if !s.canWrite(user, req.Namespace) {
+		writeError(w, http.StatusForbidden, "you do not have permission to create repositories in this namespace")
+		return
+	}
+	if _, ok := s.repos[req.Namespace+"/"+req.Name]; ok {
+		writeError(w, http.StatusConflict, "repository already exists")
+		return
+	}
+
+	repo := s.addRepository(dockerhub.Repository{
+		User:        user,
+		Name:        req.Name,
+		Namespace:   req.Namespace,
+		Description: req.Description,
+		IsPrivate:   req.IsPrivate,
+		CanEdit:     true,
+	})
+	repo.Permissions = dockerhub.RepositoryPermissions{Read: true, Write: true, Admin: true}
+	writeJSON(w, http.StatusCreated, repo)
+}
+
+// visibleRepository returns the repository ns/name if user may see it.
+func (s *Server) visibleRepository(user, namespace, name string) (*dockerhub.Repository, bool) {
+	repo, ok := s.repos[namespace+"/"+name]
+	if !ok || (repo.IsPrivate && !s.canWrite(user, namespace)) {
+		return nil, false
+	}
+	return repo, true
+}
+
+// withPermissions returns a copy of repo describing user's access to it.
+func (s *Server) withPermissions(user string, repo *dockerhub.Repository) dockerhub.Repository {
+	res := *repo
+	write := s.canWrite(user, repo.Namespace)
+	res.CanEdit = write
+	res.Permissions = dockerhub.RepositoryPermissions{Read: true, Write: write, Admin: write}
+	return res
+}
+
+// routeRepositories serves the endpoints under /repositories/.
+func (s *Server) routeRepositories(w http.ResponseWriter, r *http.Request, user string, parts []string) {
+	namespace := parts[0]
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.listRepositories(w, r, user, namespace)
+		return
+	}
+
+	repo, ok := s.visibleRepository(user, namespace, parts[1])
+	if !ok {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	write := s.canWrite(user, namespace)
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.withPermissions(user, repo))
+	case len(parts) == 3 && parts[2] == "tags" && r.Method == http.MethodGet:
+		s.listTags(w, r, repo)
+	case len(parts) == 4 && parts[2] == "tags" && r.Method == http.MethodGet:
+		s.getTag(w, repo, parts[3])
+	case user == "":
+		writeError(w, http.StatusUnauthorized, "authentication credentials were not provided")
+	case !write:
+		writeError(w, http.StatusForbidden, "you do not have permission to perform this action")
+	case len(parts) == 2 && r.Method == http.MethodPatch:
+		s.editRepository(w, r, user, repo)
+	case len(parts) == 3 && parts[2] == "privacy" && r.Method == http.MethodPost:
+		s.setPrivacy(w, r, repo)
+	case len(parts) >= 3 && parts[2] == "webhook_pipeline":
+		s.routeWebhooks(w, r, repo, parts[3:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request, user, namespace string) {
+	var repos []dockerhub.Repository
+	for _, key := range s.repoOrder {
+		repo := s.repos[key]
+		if repo.Namespace != namespace {
+			continue
+		}
+		if _, ok := s.visibleRepository(user, namespace, repo.Name); ok {
+			repos = append(repos, s.withPermissions(user, repo))
+		}
+	}
+
+	p, start, end, err := paginate(r, len(repos))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	p.Results = append([]dockerhub.Repository{}, repos[start:end]...)
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) editRepository(w http.ResponseWriter, r *http.Request, user string, repo *dockerhub.Repository) {
+	patch := &dockerhub.RepositoryPatch{}
+	if !decode(w, r, patch) {
+		return
+	}
+	if len([]rune(patch.FullDescription)) > dockerhub.MaxFullDescriptionLength {
+		writeError(w, http.StatusBadRequest, "full_description is too long")
+		return
+	}
+
+	if patch.Description != "" {
+		repo.Description = patch.Description
+	}
+	if patch.FullDescription != "" {
+		repo.FullDescription = patch.FullDescription
+	}
+	repo.LastUpdated = s.Now().UTC().Format(time.RFC3339)
+	writeJSON(w, http.StatusOK, s.withPermissions(user, repo))
+}
+
+func (s *Server) setPrivacy(w http.ResponseWriter, r *http.Request, repo *dockerhub.Repository) {
+	patch := &dockerhub.RepositoryPrivacyPatch{}
+	if !decode(w, r, patch) {
+		return
+	}
+	repo.IsPrivate = patch.IsPrivate
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request, repo *dockerhub.Repository) {
+	tags := s.tags[repo.Namespace+"/"+repo.Name]
+
+	p, start, end, err := paginate(r, len(tags))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	results := make([]dockerhub.Tag, 0, end-start)
+	for _, t := range tags[start:end] {
+		results = append(results, *t)
+	}
+	p.Results = results
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) getTag(w http.ResponseWriter, repo *dockerhub.Repository, name string) {
+	for _, t := range s.tags[repo.Namespace+"/"+repo.Name] {
+		if t.Name == name {
+			writeJSON(w, http.StatusOK, t)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "object not found")
+}
+
+// routeWebhooks serves the endpoints under webhook_pipeline/.
+func (s *Server) routeWebhooks(w http.ResponseWriter, r *http.Request, repo *dockerhub.Repository, parts []string) {
+	key := repo.Namespace + "/" + repo.Name
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			s.listWebhooks(w, r, key)
+		case http.MethodPost:
+			s.createWebhook(w, r, key)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	slug := parts[0]
+	idx := -1
+	for i, h := range s.webhooks[key] {
+		if h.Slug == slug {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	hook := s.webhooks[key][idx]
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, hook)
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		s.updateWebhook(w, r, hook)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		s.webhooks[key] = append(s.webhooks[key][:idx], s.webhooks[key][idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request, key string) {
+	hooks := s.webhooks[key]
+	p, start, end, err := paginate(r, len(hooks))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	results := make([]dockerhub.Results, 0, end-start)
+	for _, h := range hooks[start:end] {
+		results = append(results, *h)
+	}
+	p.Results = results
+	writeJSON(w, http.StatusOK, p)
+}
+
+// hookURLs returns the webhooks of a request, stamped with now.
+func hookURLs(webhooks []dockerhub.Webhooks, now time.Time) []dockerhub.Webhooks {
+	res := make([]dockerhub.Webhooks, 0, len(webhooks))
+	for _, h := range webhooks {
+		h.Created, h.LastUpdated = now, now
+		res = append(res, h)
+	}
+	return res
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request, key string) {
+	req := &dockerhub.WebhookRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	slug := slugify(req.Name)
+	if slug == "" || len(req.Webhooks) == 0 {
+		writeError(w, http.StatusBadRequest, "name and webhooks are required")
+		return
+	}
+	for _, h := range s.webhooks[key] {
+		if h.Slug == slug {
+			writeError(w, http.StatusConflict, "webhook already exists")
+			return
+		}
+	}
+
+	now := s.Now().UTC()
+	hook := &dockerhub.Results{
+		Name:                req.Name,
+		Slug:                slug,
+		ExpectFinalCallback: req.ExpectFinalCallback,
+		Created:             now,
+		LastUpdated:         now,
+		Webhooks:            hookURLs(req.Webhooks, now),
+	}
+	s.webhooks[key] = append(s.webhooks[key], hook)
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+func (s *Server) updateWebhook(w http.ResponseWriter, r *http.Request, hook *dockerhub.Results) {
+	req := &dockerhub.WebhookRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+
+	now := s.Now().UTC()
+	if req.Name != "" {
+		hook.Name = req.Name
+	}
+	if req.Webhooks != nil {
+		hook.Webhooks = hookURLs(req.Webhooks, now)
+	}
+	hook.ExpectFinalCallback = req.ExpectFinalCallback
+	hook.LastUpdated = now
+	writeJSON(w, http.StatusOK, hook)
+}
+
+// routeAccessTokens serves the endpoints under /access-tokens/.
+func (s *Server) routeAccessTokens(w http.ResponseWriter, r *http.Request, user string, parts []string) {
+	if len(parts) == 0 || parts[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.listAccessTokens(w, r, user)
+		case http.MethodPost:
+			s.createAccessToken(w, r, user)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	t, ok := s.accessTokens[parts[0]]
+	if !ok || t.owner != user || len(parts) > 1 {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, redacted(t.token))
+	case http.MethodPatch:
+		patch := &dockerhub.AccessTokenPatch{}
+		if !decode(w, r, patch) {
+			return
+		}
+		if patch.TokenLabel != nil {
+			t.token.TokenLabel = *patch.TokenLabel
+		}
+		if patch.IsActive != nil {
+			t.token.IsActive = *patch.IsActive
+		}
+		writeJSON(w, http.StatusOK, redacted(t.token))
+	case http.MethodDelete:
+		delete(s.accessTokens, parts[0])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// redacted returns the token without its secret, which Dockerhub only
+// reveals on creation.
+func redacted(t dockerhub.AccessToken) dockerhub.AccessToken {
+	t.Token = ""
+	return t
+}
+
+func (s *Server) createAccessToken(w http.ResponseWriter, r *http.Request, user string) {
+	req := &dockerhub.CreateAccessTokenRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	if req.TokenLabel == "" {
+		writeError(w, http.StatusBadRequest, "token_label is required")
+		return
+	}
+
+	t := &accessToken{
+		owner: user,
+		token: dockerhub.AccessToken{
+			UUID:        newUUID(),
+			CreatedAt:   s.Now().UTC(),
+			GeneratedBy: "manual",
+			IsActive:    true,
+			Token:       "dckr_pat_" + randomHex(16),
+			TokenLabel:  req.TokenLabel,
+			Scopes:      req.Scopes,
+		},
+	}
+	s.accessTokens[t.token.UUID] = t
+	writeJSON(w, http.StatusCreated, t.token)
+}
+
+func (s *Server) listAccessTokens(w http.ResponseWriter, r *http.Request, user string) {
+	var tokens []dockerhub.AccessToken
+	active := 0
+	for _, t := range s.accessTokens {
+		if t.owner != user {
+			continue
+		}
+		tokens = append(tokens, redacted(t.token))
+		if t.token.IsActive {
+			active++
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+		}
+		return tokens[i].UUID < tokens[j].UUID
+	})
+
+	p, start, end, err := paginate(r, len(tokens))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		page
+		ActiveCount int `json:"active_count"`
+	}{
+		page: page{
+			Count:    p.Count,
+			Next:     p.Next,
+			Previous: p.Previous,
+			Results:  append([]dockerhub.AccessToken{}, tokens[start:end]...),
+		},
+		ActiveCount: active,
+	})
+}
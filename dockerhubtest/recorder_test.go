@@ -0,0 +1,128 @@
+package dockerhubtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	srv := NewServer()
+	srv.AddUser("someone", "s3cret")
+	srv.AddRepository(dockerhub.Repository{Namespace: "someone", Name: "app", Description: "An app"})
+	baseURL, _ := url.Parse(srv.URL)
+
+	rec, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	client := dockerhub.NewClient(rec.Client())
+	client.BaseURL = baseURL
+	if err := client.Auth.Login(ctx, "someone", "s3cret"); err != nil {
+		t.Fatalf("Auth.Login returned error: %v", err)
+	}
+	token := client.AuthToken()
+	if token == Redacted {
+		t.Error("recording redacted the token returned to the client")
+	}
+	if _, err := client.Repositories.GetRepository(ctx, "someone", "app"); err != nil {
+		t.Fatalf("Repositories.GetRepository returned error: %v", err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	srv.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", token} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains secret %q:\n%s", secret, b)
+		}
+	}
+
+	// The server is gone, so the responses must come from the cassette.
+	rec, err = NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	client = dockerhub.NewClient(rec.Client())
+	client.BaseURL = baseURL
+	if err := client.Auth.Login(ctx, "someone", "another password"); err != nil {
+		t.Fatalf("Auth.Login returned error on replay: %v", err)
+	}
+	repo, err := client.Repositories.GetRepository(ctx, "someone", "app")
+	if err != nil {
+		t.Fatalf("Repositories.GetRepository returned error on replay: %v", err)
+	}
+	if repo.Description != "An app" {
+		t.Errorf("replayed repository is %+v", repo)
+	}
+
+	if _, err := client.Repositories.GetRepository(ctx, "someone", "app"); err == nil {
+		t.Error("replaying an interaction twice returned no error")
+	}
+}
+
+func TestRecorder_ScrubsTokens(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddUser("someone", "s3cret")
+	baseURL, _ := url.Parse(srv.URL)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token":"registry-token","access_token":"registry-access-token","expires_in":300}`))
+	}))
+	defer tokenServer.Close()
+
+	rec, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	client := dockerhub.NewClient(rec.Client())
+	client.BaseURL = baseURL
+	if err := client.Auth.Login(ctx, "someone", "s3cret"); err != nil {
+		t.Fatalf("Auth.Login returned error: %v", err)
+	}
+	tok, err := client.AccessTokens.CreateAccessToken(ctx, "ci", []string{"repo:read"})
+	if err != nil {
+		t.Fatalf("AccessTokens.CreateAccessToken returned error: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, tokenServer.URL+"/token?service=registry.docker.io", nil)
+	req.Header.Set("Cookie", "session=cookie-secret")
+	resp, err := rec.Client().Do(req)
+	if err != nil {
+		t.Fatalf("token request returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{tok.Token, "registry-token", "registry-access-token", "cookie-secret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains secret %q:\n%s", secret, b)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+// Package dockerhubtest provides an in-memory fake of the Dockerhub API
+// for testing code built on the dockerhub client.
+//
+// The fake keeps users, organizations, repositories, tags, webhooks and
+// access tokens in memory and serves every endpoint the client calls,
+// including login, pagination and permission checks:
+//
+//	srv := dockerhubtest.NewServer()
+//	defer srv.Close()
+//	srv.AddUser("someone", "password")
+//
+//	client := srv.Client()
+//	err := client.Auth.Login(ctx, "someone", "password")
+package dockerhubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// defaultPageSize is the page size used when a request gives none.
+const defaultPageSize = 10
+
+// maxPageSize is the largest page size Dockerhub serves.
+const maxPageSize = 100
+
+// account is a user and the secrets it can log in with.
+type account struct {
+	user     dockerhub.User
+	password string
+}
+
+// organization is an organization and the users who own it.
+type organization struct {
+	org    dockerhub.Organization
+	owners map[string]bool
+}
+
+// accessToken is a personal access token and the user it belongs to.
+type accessToken struct {
+	token dockerhub.AccessToken
+	owner string
+}
+
+// injectedError is a response returned in place of handling a request.
+type injectedError struct {
+	method, path string
+	status       int
+}
+
+// Server is a fake Dockerhub API server. Its methods are safe for
+// concurrent use.
+type Server struct {
+	// URL is the base URL of the server, suitable for Client.BaseURL.
+	URL string
+
+	// Now returns the time used for timestamps. It defaults to
+	// time.Now.
+	Now func() time.Time
+
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	seq          int
+	accounts     map[string]*account
+	sessions     map[string]string
+	orgs         map[string]*organization
+	repos        map[string]*dockerhub.Repository
+	repoOrder    []string
+	tags         map[string][]*dockerhub.Tag
+	webhooks     map[string][]*dockerhub.Results
+	accessTokens map[string]*accessToken
+	errors       []injectedError
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		Now:          time.Now,
+		accounts:     make(map[string]*account),
+		sessions:     make(map[string]string),
+		orgs:         make(map[string]*organization),
+		repos:        make(map[string]*dockerhub.Repository),
+		tags:         make(map[string][]*dockerhub.Tag),
+		webhooks:     make(map[string][]*dockerhub.Results),
+		accessTokens: make(map[string]*accessToken),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v2/", http.StripPrefix("/v2", http.HandlerFunc(s.route)))
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a dockerhub client which talks to the server.
+func (s *Server) Client() *dockerhub.Client {
+	client := dockerhub.NewClient(s.srv.Client())
+	client.BaseURL, _ = url.Parse(s.URL)
+	return client
+}
+
+// nextID returns a new unique identifier.
+func (s *Server) nextID() int {
+	s.seq++
+	return s.seq
+}
+
+// AddUser creates a user who can log in with password.
+func (s *Server) AddUser(username, password string) *dockerhub.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := &account{
+		password: password,
+		user: dockerhub.User{
+			ID:         fmt.Sprintf("%032d", s.nextID()),
+			Username:   username,
+			ProfileURL: "https://hub.docker.com/u/" + username,
+			DateJoined: s.Now().UTC(),
+			Type:       "User",
+		},
+	}
+	s.accounts[username] = a
+	u := a.user
+	return &u
+}
+
+// AddOrganization creates an organization owned by the user owner.
+func (s *Server) AddOrganization(owner, name string) *dockerhub.Organization {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addOrganization(owner, name, "")
+}
+
+func (s *Server) addOrganization(owner, name, company string) *dockerhub.Organization {
+	o := &organization{
+		owners: map[string]bool{owner: true},
+		org: dockerhub.Organization{
+			ID:         fmt.Sprintf("%032d", s.nextID()),
+			Orgname:    name,
+			Company:    company,
+			ProfileURL: "https://hub.docker.com/u/" + name,
+			DateJoined: s.Now().UTC(),
+			Type:       "Organization",
+		},
+	}
+	s.orgs[name] = o
+	org := o.org
+	return &org
+}
+
+// AddRepository creates a repository. Namespace and Name must be set.
+func (s *Server) AddRepository(repo dockerhub.Repository) *dockerhub.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addRepository(repo)
+}
+
+func (s *Server) addRepository(repo dockerhub.Repository) *dockerhub.Repository {
+	if repo.User == "" {
+		repo.User = repo.Namespace
+	}
+	if repo.RepositoryType == "" {
+		repo.RepositoryType = "image"
+	}
+	if repo.Status == 0 {
+		repo.Status = 1
+	}
+	if repo.LastUpdated == "" {
+		repo.LastUpdated = s.Now().UTC().Format(time.RFC3339)
+	}
+
+	key := repo.Namespace + "/" + repo.Name
+	if _, ok := s.repos[key]; !ok {
+		s.repoOrder = append(s.repoOrder, key)
+	}
+	r := repo
+	s.repos[key] = &r
+	return &repo
+}
+
+// AddTag adds a tag to a repository, replacing any tag with the same
+// name.
+func (s *Server) AddTag(namespace, repo string, tag dockerhub.Tag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := namespace + "/" + repo
+	if tag.ID == 0 {
+		tag.ID = s.nextID()
+	}
+	if tag.LastUpdated.IsZero() {
+		tag.LastUpdated = s.Now().UTC()
+	}
+	if tag.TagStatus == "" {
+		tag.TagStatus = "active"
+	}
+	tag.V2 = true
+
+	tags := s.tags[key]
+	for i, t := range tags {
+		if t.Name == tag.Name {
+			tags = append(tags[:i], tags[i+1:]...)
+			break
+		}
+	}
+	// Tags are kept most recently updated first, matching the
+	// last_updated ordering the client requests.
+	s.tags[key] = append([]*dockerhub.Tag{&tag}, tags...)
+}
+
+// FailNext makes the next request matching method and path, such as
+// "/repositories/someone/app/", fail with status. An empty method
+// matches any method.
+func (s *Server) FailNext(method, path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, injectedError{method, path, status})
+}
+
+// injected returns and forgets the error injected for r, if any.
+func (s *Server) injected(r *http.Request) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.errors {
+		if (e.method == "" || e.method == r.Method) && e.path == r.URL.Path {
+			s.errors = append(s.errors[:i], s.errors[i+1:]...)
+			return e.status, true
+		}
+	}
+	return 0, false
+}
+
+// writeJSON writes v as the JSON response body with status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response in the form Dockerhub uses.
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]string{"detail": detail})
+}
+
+// page is a page of a list response.
+type page struct {
+	Count    int         `json:"count"`
+	Next     *string     `json:"next"`
+	Previous *string     `json:"previous"`
+	Results  interface{} `json:"results"`
+}
+
+// paginate returns the page of n items requested by r, as the bounds of
+// the slice to serve.
+func paginate(r *http.Request, n int) (p page, start, end int, err error) {
+	q := r.URL.Query()
+
+	number := 1
+	if v := q.Get("page"); v != "" {
+		if number, err = strconv.Atoi(v); err != nil || number < 1 {
+			return page{}, 0, 0, fmt.Errorf("invalid page %q", v)
+		}
+	}
+	size := defaultPageSize
+	if v := q.Get("page_size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 {
+			return page{}, 0, 0, fmt.Errorf("invalid page_size %q", v)
+		}
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	start = (number - 1) * size
+	if start > n || (start == n && n > 0) {
+		return page{}, 0, 0, fmt.Errorf("invalid page %d", number)
+	}
+	end = start + size
+	if end > n {
+		end = n
+	}
+
+	link := func(number int) *string {
+		u := url.URL{Scheme: "http", Host: r.Host, Path: "/v2" + r.URL.Path}
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(number))
+		q.Set("page_size", strconv.Itoa(size))
+		u.RawQuery = q.Encode()
+		s := u.String()
+		return &s
+	}
+
+	p = page{Count: n}
+	if end < n {
+		p.Next = link(number + 1)
+	}
+	if number > 1 {
+		p.Previous = link(number - 1)
+	}
+	return p, start, end, nil
+}
+
+// authenticate returns the user the request is authorized as, if any.
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || (scheme != "JWT" && scheme != "Bearer") {
+		return "", false
+	}
+	username, ok := s.sessions[token]
+	return username, ok
+}
+
+// canWrite reports whether username may modify the namespace.
+func (s *Server) canWrite(username, namespace string) bool {
+	if username == namespace {
+		return true
+	}
+	o, ok := s.orgs[namespace]
+	return ok && o.owners[username]
+}
+
+// route dispatches a request to the handler of its endpoint.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if status, ok := s.injected(r); ok {
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, authed := s.authenticate(r)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/users/login/" && r.Method == http.MethodPost:
+		s.login(w, r)
+		return
+	case parts[0] == "repositories" && len(parts) >= 2 && r.Method == http.MethodGet &&
+		!(len(parts) >= 4 && parts[3] == "webhook_pipeline"):
+		// Public repositories and tags may be read anonymously.
+		s.routeRepositories(w, r, user, parts[1:])
+		return
+	}
+
+	if !authed {
+		writeError(w, http.StatusUnauthorized, "authentication credentials were not provided")
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/user/" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.accounts[user].user)
+	case r.URL.Path == "/user/orgs/" && r.Method == http.MethodGet:
+		s.listOrganizations(w, r, user)
+	case r.URL.Path == "/orgs/" && r.Method == http.MethodPost:
+		s.createOrganization(w, r, user)
+	case r.URL.Path == "/repositories/" && r.Method == http.MethodPost:
+		s.createRepository(w, r, user)
+	case parts[0] == "repositories" && len(parts) >= 2:
+		s.routeRepositories(w, r, user, parts[1:])
+	case parts[0] == "access-tokens":
+		s.routeAccessTokens(w, r, user, parts[1:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
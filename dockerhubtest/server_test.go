@@ -0,0 +1,259 @@
+package dockerhubtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+)
+
+// loggedIn returns a server with the user someone and a client logged in
+// as them.
+func loggedIn(t *testing.T) (*Server, *dockerhub.Client) {
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddUser("someone", "password")
+
+	client := srv.Client()
+	if err := client.Auth.Login(context.Background(), "someone", "password"); err != nil {
+		t.Fatalf("Auth.Login returned error: %v", err)
+	}
+	return srv, client
+}
+
+// assertStatus checks that err is an API error with status.
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var errResp *dockerhub.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != status {
+		t.Errorf("error is %v; want status %d", err, status)
+	}
+}
+
+func TestServer_Login(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddUser("someone", "password")
+	ctx := context.Background()
+
+	client := srv.Client()
+	assertStatus(t, client.Auth.Login(ctx, "someone", "wrong"), http.StatusUnauthorized)
+	_, err := client.User.GetLoggedInUser(ctx)
+	assertStatus(t, err, http.StatusUnauthorized)
+
+	if err := client.Auth.Login(ctx, "someone", "password"); err != nil {
+		t.Fatalf("Auth.Login returned error: %v", err)
+	}
+	user, err := client.User.GetLoggedInUser(ctx)
+	if err != nil {
+		t.Fatalf("User.GetLoggedInUser returned error: %v", err)
+	}
+	if user.Username != "someone" {
+		t.Errorf("username is %q; want someone", user.Username)
+	}
+}
+
+func TestServer_Repositories(t *testing.T) {
+	srv, client := loggedIn(t)
+	ctx := context.Background()
+
+	repo, err := client.Repositories.CreateRepository(ctx, "someone", "app", "An app", false)
+	if err != nil {
+		t.Fatalf("Repositories.CreateRepository returned error: %v", err)
+	}
+	if repo.Namespace != "someone" || repo.Name != "app" || repo.Description != "An app" {
+		t.Errorf("Repositories.CreateRepository returned %+v", repo)
+	}
+	_, err = client.Repositories.CreateRepository(ctx, "someone", "app", "", false)
+	assertStatus(t, err, http.StatusConflict)
+	_, err = client.Repositories.CreateRepository(ctx, "other", "app", "", false)
+	assertStatus(t, err, http.StatusForbidden)
+
+	if err := client.Repositories.SetRepositoryPrivacy(ctx, "someone", "app", true); err != nil {
+		t.Fatalf("Repositories.SetRepositoryPrivacy returned error: %v", err)
+	}
+	edited, err := client.Repositories.EditRepository(ctx, "someone", "app", &dockerhub.RepositoryPatch{FullDescription: "# App"})
+	if err != nil {
+		t.Fatalf("Repositories.EditRepository returned error: %v", err)
+	}
+	if !edited.IsPrivate || edited.FullDescription != "# App" || edited.Description != "An app" {
+		t.Errorf("Repositories.EditRepository returned %+v", edited)
+	}
+
+	// Private repositories are hidden from everyone else.
+	anonymous := srv.Client()
+	_, err = anonymous.Repositories.GetRepository(ctx, "someone", "app")
+	assertStatus(t, err, http.StatusNotFound)
+	srv.AddRepository(dockerhub.Repository{Namespace: "someone", Name: "public"})
+	list, err := anonymous.Repositories.GetRepositories(ctx, "someone")
+	if err != nil {
+		t.Fatalf("Repositories.GetRepositories returned error: %v", err)
+	}
+	if list.Count != 1 || list.Results[0].Name != "public" {
+		t.Errorf("Repositories.GetRepositories returned %+v", list)
+	}
+}
+
+func TestServer_Pagination(t *testing.T) {
+	srv, client := loggedIn(t)
+	ctx := context.Background()
+
+	srv.AddRepository(dockerhub.Repository{Namespace: "someone", Name: "app"})
+	for i := 0; i < 12; i++ {
+		srv.AddTag("someone", "app", dockerhub.Tag{Name: fmt.Sprintf("1.%d", i)})
+	}
+
+	tags, err := client.Tag.GetTags(ctx, "someone", "app", 5)
+	if err != nil {
+		t.Fatalf("Tag.GetTags returned error: %v", err)
+	}
+	if tags.Count != 12 || len(tags.Results) != 5 || tags.Next == nil || tags.Previous != nil {
+		t.Fatalf("Tag.GetTags returned %d of %d tags, next %v, previous %v", len(tags.Results), tags.Count, tags.Next, tags.Previous)
+	}
+	if tags.Results[0].Name != "1.11" {
+		t.Errorf("first tag is %q; want the most recent, 1.11", tags.Results[0].Name)
+	}
+
+	tag, err := client.Tag.GetTag(ctx, "someone", "app", "1.3")
+	if err != nil {
+		t.Fatalf("Tag.GetTag returned error: %v", err)
+	}
+	if tag.Name != "1.3" {
+		t.Errorf("Tag.GetTag returned %+v", tag)
+	}
+	_, err = client.Tag.GetTag(ctx, "someone", "app", "2.0")
+	assertStatus(t, err, http.StatusNotFound)
+
+	tokens, err := client.AccessTokens.GetAccessTokens(ctx, 3, 10)
+	assertStatus(t, err, http.StatusNotFound)
+	if tokens != nil {
+		t.Errorf("AccessTokens.GetAccessTokens returned %+v past the last page", tokens)
+	}
+}
+
+func TestServer_Organizations(t *testing.T) {
+	_, client := loggedIn(t)
+	ctx := context.Background()
+
+	if _, err := client.Organization.CreateOrganization(ctx, "myorg", "My Company"); err != nil {
+		t.Fatalf("Organization.CreateOrganization returned error: %v", err)
+	}
+	_, err := client.Organization.CreateOrganization(ctx, "someone", "")
+	assertStatus(t, err, http.StatusConflict)
+
+	orgs, err := client.Organization.GetOrganizations(ctx, 10)
+	if err != nil {
+		t.Fatalf("Organization.GetOrganizations returned error: %v", err)
+	}
+	if orgs.Count != 1 || orgs.Results[0].Orgname != "myorg" || orgs.Results[0].Company != "My Company" {
+		t.Errorf("Organization.GetOrganizations returned %+v", orgs)
+	}
+
+	// Owners may create repositories in the organization.
+	if _, err := client.Repositories.CreateRepository(ctx, "myorg", "app", "", true); err != nil {
+		t.Errorf("Repositories.CreateRepository returned error: %v", err)
+	}
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	srv, client := loggedIn(t)
+	ctx := context.Background()
+	srv.AddRepository(dockerhub.Repository{Namespace: "someone", Name: "app"})
+
+	if _, err := client.Webhook.CreateWebhook(ctx, "someone", "app", "Deploy Hook", "https://example.com/hook"); err != nil {
+		t.Fatalf("Webhook.CreateWebhook returned error: %v", err)
+	}
+	hook, err := client.Webhook.GetWebhook(ctx, "someone", "app", "deploy-hook")
+	if err != nil {
+		t.Fatalf("Webhook.GetWebhook returned error: %v", err)
+	}
+	if hook.Name != "Deploy Hook" || len(hook.Webhooks) != 1 || hook.Webhooks[0].HookURL != "https://example.com/hook" {
+		t.Errorf("Webhook.GetWebhook returned %+v", hook)
+	}
+
+	hook, err = client.Webhook.UpdateWebhook(ctx, "someone", "app", "deploy-hook", &dockerhub.WebhookRequest{ExpectFinalCallback: true})
+	if err != nil {
+		t.Fatalf("Webhook.UpdateWebhook returned error: %v", err)
+	}
+	if !hook.ExpectFinalCallback || hook.Name != "Deploy Hook" {
+		t.Errorf("Webhook.UpdateWebhook returned %+v", hook)
+	}
+
+	// Webhooks are not visible to other users, even on public repositories.
+	srv.AddUser("other", "password")
+	other := srv.Client()
+	if err := other.Auth.Login(ctx, "other", "password"); err != nil {
+		t.Fatalf("Auth.Login returned error: %v", err)
+	}
+	_, err = other.Webhook.GetWebhooks(ctx, "someone", "app")
+	assertStatus(t, err, http.StatusForbidden)
+
+	if err := client.Webhook.DeleteWebhook(ctx, "someone", "app", "deploy-hook"); err != nil {
+		t.Fatalf("Webhook.DeleteWebhook returned error: %v", err)
+	}
+	_, err = client.Webhook.GetWebhook(ctx, "someone", "app", "deploy-hook")
+	assertStatus(t, err, http.StatusNotFound)
+}
+
+func TestServer_AccessTokens(t *testing.T) {
+	srv, client := loggedIn(t)
+	ctx := context.Background()
+
+	token, err := client.AccessTokens.CreateAccessToken(ctx, "ci", []string{dockerhub.ScopeRepoRead})
+	if err != nil {
+		t.Fatalf("AccessTokens.CreateAccessToken returned error: %v", err)
+	}
+	if token.Token == "" || !token.IsActive {
+		t.Fatalf("AccessTokens.CreateAccessToken returned %+v", token)
+	}
+
+	got, err := client.AccessTokens.GetAccessToken(ctx, token.UUID)
+	if err != nil {
+		t.Fatalf("AccessTokens.GetAccessToken returned error: %v", err)
+	}
+	if got.Token != "" {
+		t.Error("AccessTokens.GetAccessToken revealed the token secret")
+	}
+
+	// The token can be used in place of the password.
+	tokenClient := srv.Client()
+	if err := tokenClient.Auth.Login(ctx, "someone", token.Token); err != nil {
+		t.Errorf("Auth.Login with access token returned error: %v", err)
+	}
+
+	if _, err := client.AccessTokens.SetAccessTokenActive(ctx, token.UUID, false); err != nil {
+		t.Fatalf("AccessTokens.SetAccessTokenActive returned error: %v", err)
+	}
+	assertStatus(t, tokenClient.Auth.Login(ctx, "someone", token.Token), http.StatusUnauthorized)
+
+	list, err := client.AccessTokens.GetAccessTokens(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("AccessTokens.GetAccessTokens returned error: %v", err)
+	}
+	if list.Count != 1 || list.ActiveCount != 0 {
+		t.Errorf("AccessTokens.GetAccessTokens returned %+v", list)
+	}
+
+	if err := client.AccessTokens.DeleteAccessToken(ctx, token.UUID); err != nil {
+		t.Fatalf("AccessTokens.DeleteAccessToken returned error: %v", err)
+	}
+	_, err = client.AccessTokens.GetAccessToken(ctx, token.UUID)
+	assertStatus(t, err, http.StatusNotFound)
+}
+
+func TestServer_FailNext(t *testing.T) {
+	srv, client := loggedIn(t)
+	ctx := context.Background()
+	srv.AddRepository(dockerhub.Repository{Namespace: "someone", Name: "app"})
+
+	srv.FailNext(http.MethodGet, "/repositories/someone/app/", http.StatusServiceUnavailable)
+	_, err := client.Repositories.GetRepository(ctx, "someone", "app")
+	assertStatus(t, err, http.StatusServiceUnavailable)
+
+	if _, err := client.Repositories.GetRepository(ctx, "someone", "app"); err != nil {
+		t.Errorf("Repositories.GetRepository returned error after the injected failure: %v", err)
+	}
+}
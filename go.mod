@@ -1,2 +1,2 @@
 module github.com/ErKiran/dockerhub-go
-go 1.19
+go 1.21
@@ -5,6 +5,16 @@ import (
 	"net/http"
 )
 
+// ErrorResponse reports an unsuccessful response from the API.
+type ErrorResponse struct {
+	Response   *http.Response
+	StatusCode int
+}
+
+func (r *ErrorResponse) Error() string {
+	return fmt.Sprintf("request failed with status %d", r.StatusCode)
+}
+
 // checkResponse checks a given HTTP response for errors and returns
 // them if present.
 func checkResponse(r *http.Response) error {
@@ -13,5 +23,5 @@ func checkResponse(r *http.Response) error {
 		return nil
 	}
 
-	return fmt.Errorf("request failed with status %d", status)
+	return &ErrorResponse{Response: r, StatusCode: status}
 }
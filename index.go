@@ -0,0 +1,68 @@
+package dockerhub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CreateIndex creates an OCI image index from single-platform images
+// already pushed to a repository and pushes it under tag. Each source is
+// a tag or digest in the same repository; its platform is read from the
+// image config. Two sources for the same platform are rejected.
+func (s *RegistryService) CreateIndex(ctx context.Context, namespace, repo, tag string, sources []string) (*Descriptor, error) {
+	ctx = withOperation(ctx, "Registry.CreateIndex", namespace, repo)
+	if len(sources) == 0 {
+		return nil, errors.New("no source images given")
+	}
+
+	index := &Index{SchemaVersion: 2, MediaType: MediaTypeOCIIndex}
+	seen := make(map[string]string)
+
+	for _, source := range sources {
+		m, err := s.GetManifest(ctx, namespace, repo, source)
+		if err != nil {
+			return nil, err
+		}
+		if m.IsIndex() {
+			return nil, fmt.Errorf("%s is already an index", source)
+		}
+
+		config, err := s.GetImageConfig(ctx, namespace, repo, m.Manifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		platform := &Platform{
+			Architecture: config.Architecture,
+			OS:           config.OS,
+			OSVersion:    config.OSVersion,
+			Variant:      config.Variant,
+		}
+		if platform.OS == "" || platform.Architecture == "" {
+			return nil, fmt.Errorf("%s has no platform in its config", source)
+		}
+
+		key := platform.String()
+		if platform.OSVersion != "" {
+			key += ":" + platform.OSVersion
+		}
+		if other, ok := seen[key]; ok {
+			return nil, fmt.Errorf("%s and %s are both for platform %s", other, source, platform)
+		}
+		seen[key] = source
+
+		index.Manifests = append(index.Manifests, Descriptor{
+			MediaType: m.MediaType,
+			Digest:    m.Digest,
+			Size:      int64(len(m.Raw)),
+			Platform:  platform,
+		})
+	}
+
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	return s.PutManifest(ctx, namespace, repo, tag, MediaTypeOCIIndex, raw)
+}
@@ -0,0 +1,59 @@
+package dockerhub
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryService_CreateIndex(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	amd64 := reg.pushImage("myorg/app", "1.4.0-amd64", Platform{OS: "linux", Architecture: "amd64"}, "amd64 layer")
+	arm64 := reg.pushImage("myorg/app", "1.4.0-arm64", Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, "arm64 layer")
+
+	d, err := client.Registry.CreateIndex(context.Background(), "myorg", "app", "1.4.0", []string{"1.4.0-amd64", "1.4.0-arm64"})
+	if err != nil {
+		t.Fatalf("Registry.CreateIndex returned error: %v", err)
+	}
+
+	m, ok := reg.manifest("myorg/app", "1.4.0")
+	if !ok {
+		t.Fatalf("index was not pushed")
+	}
+	if got := digestOf(m.raw); got != d.Digest {
+		t.Errorf("pushed digest is %s; want %s", got, d.Digest)
+	}
+	if m.mediaType != MediaTypeOCIIndex {
+		t.Errorf("media type is %s; want %s", m.mediaType, MediaTypeOCIIndex)
+	}
+
+	index := &Index{}
+	if err := json.Unmarshal(m.raw, index); err != nil {
+		t.Fatalf("decoding index: %v", err)
+	}
+	if want := []Descriptor{amd64, arm64}; !reflect.DeepEqual(index.Manifests, want) {
+		t.Errorf("manifests are %v; want %v", index.Manifests, want)
+	}
+}
+
+func TestRegistryService_CreateIndex_PlatformCollision(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	reg.pushImage("myorg/app", "a", Platform{OS: "linux", Architecture: "amd64"}, "a")
+	reg.pushImage("myorg/app", "b", Platform{OS: "linux", Architecture: "amd64"}, "b")
+
+	_, err := client.Registry.CreateIndex(context.Background(), "myorg", "app", "1.4.0", []string{"a", "b"})
+	if want := "a and b are both for platform linux/amd64"; err == nil || err.Error() != want {
+		t.Errorf("Registry.CreateIndex error is %v; want %s", err, want)
+	}
+
+	if _, ok := reg.manifest("myorg/app", "1.4.0"); ok {
+		t.Errorf("index was pushed despite the collision")
+	}
+}
@@ -0,0 +1,178 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultPlatform is inspected when no platform is given.
+const defaultPlatform = "linux/amd64"
+
+// ImageInspect describes an image for one platform, combining the
+// Dockerhub tag metadata with the manifest and config served by the
+// registry.
+type ImageInspect struct {
+	Namespace  string
+	Repository string
+	Reference  string
+
+	// Digest is the digest of the platform's image manifest. When the
+	// reference resolves to an index, IndexDigest is its digest.
+	Digest      string
+	IndexDigest string
+	MediaType   string
+	Platform    Platform
+
+	Created      *time.Time
+	Author       string
+	User         string
+	WorkingDir   string
+	Entrypoint   []string
+	Cmd          []string
+	Env          []string
+	Labels       map[string]string
+	ExposedPorts []string
+	History      []History
+
+	Config Descriptor
+	Layers []LayerInspect
+	Size   int64
+
+	// Tag is the Dockerhub metadata of the tag, or nil when the image
+	// was referenced by digest.
+	Tag *Tag
+}
+
+// LayerInspect describes a layer of an image.
+type LayerInspect struct {
+	MediaType string
+	Digest    string
+	Size      int64
+	DiffID    string
+}
+
+// ParsePlatform parses a platform in os/arch[/variant] form, such as
+// "linux/arm64/v8".
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q", s)
+	}
+
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// matches reports whether p satisfies want. An empty variant in want
+// matches any variant.
+func (p Platform) matches(want Platform) bool {
+	if p.OS != want.OS || p.Architecture != want.Architecture {
+		return false
+	}
+	return want.Variant == "" || p.Variant == want.Variant
+}
+
+// resolvePlatform returns the image manifest for platform, following the
+// index m when it is one.
+func (s *RegistryService) resolvePlatform(ctx context.Context, namespace, repo string, m *ManifestResponse, platform Platform) (*ManifestResponse, error) {
+	if !m.IsIndex() {
+		return m, nil
+	}
+
+	for _, d := range m.Index.Manifests {
+		if d.Platform == nil || !d.Platform.matches(platform) {
+			continue
+		}
+		return s.GetManifest(ctx, namespace, repo, d.Digest)
+	}
+	return nil, fmt.Errorf("no manifest for platform %s in %s", platform, m.Digest)
+}
+
+// Inspect describes the image a tag or digest resolves to for the given
+// platform, in os/arch[/variant] form. An empty platform means
+// linux/amd64; it is ignored for references which are not an index.
+// An empty namespace means the "library" namespace of official images.
+func (s *RegistryService) Inspect(ctx context.Context, namespace, repo, reference, platform string) (*ImageInspect, error) {
+	if namespace == "" {
+		namespace = "library"
+	}
+	ctx = withOperation(ctx, "Registry.Inspect", namespace, repo)
+	if platform == "" {
+		platform = defaultPlatform
+	}
+	want, err := ParsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImageInspect{
+		Namespace:  namespace,
+		Repository: repo,
+		Reference:  reference,
+	}
+
+	if !strings.HasPrefix(reference, "sha256:") {
+		if res.Tag, err = s.client.Tag.GetTag(ctx, namespace, repo, reference); err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := s.GetManifest(ctx, namespace, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+	if m.IsIndex() {
+		res.IndexDigest = m.Digest
+		if m, err = s.resolvePlatform(ctx, namespace, repo, m, want); err != nil {
+			return nil, err
+		}
+	}
+	if m.IsIndex() {
+		return nil, fmt.Errorf("nested index %s is not supported", m.Digest)
+	}
+
+	config, err := s.GetImageConfig(ctx, namespace, repo, m.Manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Digest = m.Digest
+	res.MediaType = m.MediaType
+	res.Platform = Platform{
+		Architecture: config.Architecture,
+		OS:           config.OS,
+		OSVersion:    config.OSVersion,
+		Variant:      config.Variant,
+	}
+	res.Created = config.Created
+	res.Author = config.Author
+	res.User = config.Config.User
+	res.WorkingDir = config.Config.WorkingDir
+	res.Entrypoint = config.Config.Entrypoint
+	res.Cmd = config.Config.Cmd
+	res.Env = config.Config.Env
+	res.Labels = config.Config.Labels
+	res.History = config.History
+	res.Config = m.Manifest.Config
+
+	for port := range config.Config.ExposedPorts {
+		res.ExposedPorts = append(res.ExposedPorts, port)
+	}
+	sort.Strings(res.ExposedPorts)
+
+	for i, l := range m.Manifest.Layers {
+		layer := LayerInspect{MediaType: l.MediaType, Digest: l.Digest, Size: l.Size}
+		if i < len(config.RootFS.DiffIDs) {
+			layer.DiffID = config.RootFS.DiffIDs[i]
+		}
+		res.Layers = append(res.Layers, layer)
+		res.Size += l.Size
+	}
+	return res, nil
+}
@@ -0,0 +1,173 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePlatform(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Platform
+		err  bool
+	}{
+		{"linux/amd64", Platform{OS: "linux", Architecture: "amd64"}, false},
+		{"linux/arm64/v8", Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, false},
+		{"linux", Platform{}, true},
+		{"linux//v8", Platform{}, true},
+	} {
+		got, err := ParsePlatform(tc.in)
+		if (err != nil) != tc.err {
+			t.Errorf("ParsePlatform(%q) error is %v; want error %v", tc.in, err, tc.err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParsePlatform(%q) is %v; want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRegistryService_Inspect(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	hub, hubMux, hubTeardown := makeMockClient()
+	defer hubTeardown()
+	client.BaseURL = hub.BaseURL
+
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := mustJSONMarshal(&ImageConfig{
+		Architecture: "arm64",
+		OS:           "linux",
+		Variant:      "v8",
+		Created:      &created,
+		Config: ContainerConfig{
+			Entrypoint:   []string{"/entrypoint.sh"},
+			Cmd:          []string{"serve"},
+			Env:          []string{"PATH=/usr/bin"},
+			Labels:       map[string]string{"maintainer": "someone"},
+			ExposedPorts: map[string]struct{}{"8080/tcp": {}, "443/tcp": {}},
+		},
+		RootFS:  RootFS{Type: "layers", DiffIDs: []string{"sha256:diff1", "sha256:diff2"}},
+		History: []History{{CreatedBy: "ADD file"}, {CreatedBy: "RUN make"}},
+	})
+	manifest := mustJSONMarshal(&Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		Config:        Descriptor{MediaType: MediaTypeOCIConfig, Digest: digestOf(config), Size: int64(len(config))},
+		Layers: []Descriptor{
+			{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: "sha256:layer1", Size: 100},
+			{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: "sha256:layer2", Size: 50},
+		},
+	})
+	index := mustJSONMarshal(&Index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIIndex,
+		Manifests: []Descriptor{
+			{MediaType: MediaTypeOCIManifest, Digest: "sha256:amd64", Size: 1, Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{MediaType: MediaTypeOCIManifest, Digest: digestOf(manifest), Size: int64(len(manifest)), Platform: &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	})
+
+	mux.HandleFunc("/v2/someone/app/manifests/1.0", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeOCIIndex)
+		w.Write(index)
+	})
+	mux.HandleFunc("/v2/someone/app/manifests/"+digestOf(manifest), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeOCIManifest)
+		w.Write(manifest)
+	})
+	mux.HandleFunc("/v2/someone/app/blobs/"+digestOf(config), func(w http.ResponseWriter, r *http.Request) {
+		w.Write(config)
+	})
+
+	tag := &Tag{Name: "1.0", Digest: digestOf(index)}
+	hubMux.HandleFunc("/repositories/someone/app/tags/1.0/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mustJSONMarshal(tag))
+	})
+
+	res, err := client.Registry.Inspect(context.Background(), "someone", "app", "1.0", "linux/arm64")
+	if err != nil {
+		t.Fatalf("Registry.Inspect returned error: %v", err)
+	}
+
+	want := &ImageInspect{
+		Namespace:    "someone",
+		Repository:   "app",
+		Reference:    "1.0",
+		Digest:       digestOf(manifest),
+		IndexDigest:  digestOf(index),
+		MediaType:    MediaTypeOCIManifest,
+		Platform:     Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		Created:      &created,
+		Entrypoint:   []string{"/entrypoint.sh"},
+		Cmd:          []string{"serve"},
+		Env:          []string{"PATH=/usr/bin"},
+		Labels:       map[string]string{"maintainer": "someone"},
+		ExposedPorts: []string{"443/tcp", "8080/tcp"},
+		History:      []History{{CreatedBy: "ADD file"}, {CreatedBy: "RUN make"}},
+		Config:       Descriptor{MediaType: MediaTypeOCIConfig, Digest: digestOf(config), Size: int64(len(config))},
+		Layers: []LayerInspect{
+			{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: "sha256:layer1", Size: 100, DiffID: "sha256:diff1"},
+			{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: "sha256:layer2", Size: 50, DiffID: "sha256:diff2"},
+		},
+		Size: 150,
+		Tag:  tag,
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("inspect is %+v; want %+v", res, want)
+	}
+}
+
+func TestRegistryService_Inspect_Official(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	hub, hubMux, hubTeardown := makeMockClient()
+	defer hubTeardown()
+	client.BaseURL = hub.BaseURL
+
+	config := mustJSONMarshal(&ImageConfig{Architecture: "amd64", OS: "linux"})
+	manifest := mustJSONMarshal(&Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		Config:        Descriptor{MediaType: MediaTypeOCIConfig, Digest: digestOf(config), Size: int64(len(config))},
+	})
+	mux.HandleFunc("/v2/library/ubuntu/manifests/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeOCIManifest)
+		w.Write(manifest)
+	})
+	mux.HandleFunc("/v2/library/ubuntu/blobs/"+digestOf(config), func(w http.ResponseWriter, r *http.Request) {
+		w.Write(config)
+	})
+	hubMux.HandleFunc("/repositories/library/ubuntu/tags/latest/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mustJSONMarshal(&Tag{Name: "latest", Digest: digestOf(manifest)}))
+	})
+
+	res, err := client.Registry.Inspect(context.Background(), "", "ubuntu", "latest", "")
+	if err != nil {
+		t.Fatalf("Registry.Inspect returned error: %v", err)
+	}
+	if res.Namespace != "library" || res.Tag == nil || res.Tag.Name != "latest" || res.Digest != digestOf(manifest) {
+		t.Errorf("inspect is %+v; want library/ubuntu:latest with its tag", res)
+	}
+}
+
+func TestRegistryService_Inspect_NoPlatform(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	index := mustJSONMarshal(&Index{SchemaVersion: 2, MediaType: MediaTypeOCIIndex})
+	digest := digestOf(index)
+	mux.HandleFunc("/v2/someone/app/manifests/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(index)
+	})
+
+	_, err := client.Registry.Inspect(context.Background(), "someone", "app", digest, "")
+	if want := fmt.Sprintf("no manifest for platform linux/amd64 in %s", digest); err == nil || err.Error() != want {
+		t.Errorf("Registry.Inspect error is %v; want %s", err, want)
+	}
+}
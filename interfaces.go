@@ -0,0 +1,68 @@
+package dockerhub
+
+import "context"
+
+// RepositoriesAPI is the interface implemented by RepositoriesService, for
+// substituting it in tests.
+type RepositoriesAPI interface {
+	CreateRepository(ctx context.Context, namespace, name, description string, isPrivate bool) (*Repository, error)
+	EditRepository(ctx context.Context, namespace, repo string, patch *RepositoryPatch) (*Repository, error)
+	GetRepository(ctx context.Context, namespace, repo string) (*Repository, error)
+	SetRepositoryPrivacy(ctx context.Context, namespace, repo string, isPrivate bool) error
+	GetRepositories(ctx context.Context, namespace string) (*RepositoryList, error)
+	SyncReadme(ctx context.Context, namespace, repo, filename string, opts *SyncReadmeOptions) (bool, error)
+}
+
+// TagsAPI is the interface implemented by TagService.
+type TagsAPI interface {
+	GetTags(ctx context.Context, namespace, repo string, page int) (*Tags, error)
+	GetTag(ctx context.Context, namespace, repo, tag string) (*Tag, error)
+}
+
+// WebhooksAPI is the interface implemented by WebhookService.
+type WebhooksAPI interface {
+	CreateWebhook(ctx context.Context, namespace, repo, name, url string) (*WebhookResponse, error)
+	GetWebhooks(ctx context.Context, namespace, repo string) (*WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, namespace, repo, name string) error
+	GetWebhook(ctx context.Context, namespace, repo, slug string) (*Results, error)
+	UpdateWebhook(ctx context.Context, namespace, repo, slug string, hook *WebhookRequest) (*Results, error)
+}
+
+// OrganizationsAPI is the interface implemented by OrganizationService.
+type OrganizationsAPI interface {
+	CreateOrganization(ctx context.Context, organization, company string) (*Organization, error)
+	GetOrganizations(ctx context.Context, pageSize int) (*OrganizationList, error)
+}
+
+// UserAPI is the interface implemented by UserService.
+type UserAPI interface {
+	GetLoggedInUser(ctx context.Context) (*User, error)
+}
+
+// AuthAPI is the interface implemented by AuthService.
+type AuthAPI interface {
+	Login(ctx context.Context, username, password string) error
+	LoginWithStore(ctx context.Context, store CredentialStore) error
+}
+
+// AccessTokensAPI is the interface implemented by AccessTokenService.
+type AccessTokensAPI interface {
+	CreateAccessToken(ctx context.Context, label string, scopes []string) (*AccessToken, error)
+	GetAccessTokens(ctx context.Context, page, pageSize int) (*AccessTokenList, error)
+	GetAccessToken(ctx context.Context, uuid string) (*AccessToken, error)
+	UpdateAccessToken(ctx context.Context, uuid string, patch *AccessTokenPatch) (*AccessToken, error)
+	SetAccessTokenActive(ctx context.Context, uuid string, isActive bool) (*AccessToken, error)
+	RenameAccessToken(ctx context.Context, uuid, label string) (*AccessToken, error)
+	DeleteAccessToken(ctx context.Context, uuid string) error
+	RotateToken(ctx context.Context, uuid string, verify func(context.Context, *AccessToken) error) (*AccessToken, error)
+}
+
+var (
+	_ RepositoriesAPI  = (*RepositoriesService)(nil)
+	_ TagsAPI          = (*TagService)(nil)
+	_ WebhooksAPI      = (*WebhookService)(nil)
+	_ OrganizationsAPI = (*OrganizationService)(nil)
+	_ UserAPI          = (*UserService)(nil)
+	_ AuthAPI          = (*AuthService)(nil)
+	_ AccessTokensAPI  = (*AccessTokenService)(nil)
+)
@@ -0,0 +1,125 @@
+package dockerhub
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces the requests of a Client with a token bucket, so that
+// large jobs stay under Dockerhub's rate limits instead of tripping them.
+// It is safe for concurrent use, and can be shared by several clients
+// using the same credentials.
+//
+// Besides its configured rate, it follows the X-RateLimit-Remaining and
+// X-RateLimit-Reset headers of responses: requests are spread over the
+// remaining budget until the reset, and held back until then once it is
+// used up or a request is rejected with 429 Too Many Requests. Only
+// requests which reach the network are paced: responses served by
+// middleware, such as cache hits, pass straight through.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// remaining and reset are the budget last reported by the API.
+	remaining int
+	reset     time.Time
+}
+
+// NewRateLimiter returns a limiter allowing perSecond requests a second
+// on average, in bursts of up to burst requests. With a perSecond of
+// zero, requests are paced by the rate limit headers alone.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be sent, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve(time.Now())
+		if wait <= 0 {
+			return ctx.Err()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token if one is available at now, and otherwise returns
+// how long to wait before trying again.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := l.rate
+	if now.Before(l.reset) {
+		if l.remaining <= 0 {
+			return l.reset.Sub(now)
+		}
+		// Spread what is left of the budget over the time to the reset.
+		if budget := float64(l.remaining) / l.reset.Sub(now).Seconds(); rate <= 0 || budget < rate {
+			rate = budget
+		}
+	}
+	if rate <= 0 {
+		// Without a configured rate, only the API's budget applies.
+		l.last = now
+		return 0
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		if now.Before(l.reset) {
+			l.remaining--
+		}
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / rate * float64(time.Second))
+}
+
+// observe updates the budget from the rate limit headers of a response.
+func (l *RateLimiter) observe(resp *http.Response, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		l.remaining, l.reset = 0, rateLimitReset(resp, now)
+		return
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	l.remaining, l.reset = remaining, time.Unix(epoch, 0)
+}
@@ -0,0 +1,126 @@
+package dockerhub
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(10, 2)
+	l.last = now
+
+	for i, want := range []time.Duration{0, 0, 100 * time.Millisecond} {
+		if got := l.reserve(now); got != want {
+			t.Errorf("reservation %d waits %v; want %v", i, got, want)
+		}
+	}
+	if got := l.reserve(now.Add(100 * time.Millisecond)); got != 0 {
+		t.Errorf("reservation after refill waits %v; want 0", got)
+	}
+}
+
+func TestRateLimiter_observe(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(10, 1)
+	l.last = now
+
+	// One request left for the next 10 seconds: spread out to 0.1/s.
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "1")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(10*time.Second).Unix(), 10))
+	l.observe(resp, now)
+
+	if got := l.reserve(now); got != 0 {
+		t.Errorf("first reservation waits %v; want 0", got)
+	}
+	if got, want := l.reserve(now), 10*time.Second; got != want {
+		t.Errorf("reservation with the budget used up waits %v; want %v", got, want)
+	}
+
+	// A 429 holds requests back until its Retry-After.
+	resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "30")
+	l.observe(resp, now)
+	if got, want := l.reserve(now), 30*time.Second; got != want {
+		t.Errorf("reservation after a 429 waits %v; want %v", got, want)
+	}
+	if got := l.reserve(now.Add(30 * time.Second)); got != 0 {
+		t.Errorf("reservation after the reset waits %v; want 0", got)
+	}
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	client.RateLimiter = NewRateLimiter(20, 1)
+
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := client.User.GetLoggedInUser(context.Background()); err != nil {
+			t.Fatalf("User.GetLoggedInUser returned error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 requests at 20/s took %v; want at least 150ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.User.GetLoggedInUser(ctx); err != context.Canceled {
+		t.Errorf("User.GetLoggedInUser with a canceled context returned %v; want context.Canceled", err)
+	}
+}
+
+func TestClient_RateLimiterWithCache(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	limiter := NewRateLimiter(0.001, 1)
+	client.RateLimiter = limiter
+	client.Use(CacheMiddleware(&CacheOptions{TTL: time.Hour}))
+
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "50")
+		w.Header().Set("X-RateLimit-Reset", reset)
+		w.Write([]byte(`{"name":"app"}`))
+	})
+
+	// The first request uses up the only token; cache hits must not wait
+	// for another.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i, want := range []string{CacheMiss, CacheHit, CacheHit} {
+		req, err := client.NewRequest(http.MethodGet, "/repositories/someone/app/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(ctx, req, &Repository{})
+		if err != nil {
+			t.Fatalf("request %d returned error: %v", i, err)
+		}
+		if got := resp.Header.Get(CacheHeader); got != want {
+			t.Errorf("request %d was a cache %s; want %s", i, got, want)
+		}
+	}
+
+	// Once the API reports the budget used up, the headers stored with a
+	// cached response must not restore it.
+	used := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	used.Header.Set("X-RateLimit-Remaining", "0")
+	used.Header.Set("X-RateLimit-Reset", reset)
+	limiter.observe(used, time.Now())
+	if _, err := client.Repositories.GetRepository(ctx, "someone", "app"); err != nil {
+		t.Fatalf("cache hit returned error: %v", err)
+	}
+	if limiter.remaining != 0 {
+		t.Errorf("budget after a cache hit is %d; want 0", limiter.remaining)
+	}
+}
@@ -0,0 +1,174 @@
+package dockerhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLoggedBody is the number of bytes of a body logged in verbose mode.
+const maxLoggedBody = 64 << 10
+
+// redactedFields are the JSON fields whose values are never logged.
+var redactedFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+// rateLimitHeaders are the response headers Dockerhub and its registry
+// report rate limits in.
+var rateLimitHeaders = []string{
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+}
+
+type attemptKey struct{}
+
+// withAttempt returns a context marking requests sent with it as attempt
+// n of a request.
+func withAttempt(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, n)
+}
+
+// attemptFromContext returns the attempt a request is, starting from 1.
+func attemptFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// logRequest logs a request sent by the client and its outcome: at debug
+// level when it succeeded, as a warning when the API returned an error
+// status and as an error when no response was received.
+func (c *Client) logRequest(ctx context.Context, req *http.Request, reqBody []byte, resp *http.Response, err error, latency time.Duration) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+		slog.Duration("latency", latency),
+		slog.Int("attempt", attemptFromContext(ctx)),
+	}
+	if op, ok := OperationFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("operation", op.Name))
+	}
+	if c.LogBodies && reqBody != nil {
+		attrs = append(attrs, slog.String("request_body", redactBody(reqBody)))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		c.Logger.LogAttrs(ctx, slog.LevelError, "dockerhub request failed", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	if v := resp.Header.Get(CacheHeader); v != "" {
+		attrs = append(attrs, slog.String("cache", v))
+	}
+	for _, h := range rateLimitHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			attrs = append(attrs, slog.String(strings.ToLower(h), v))
+		}
+	}
+	if c.LogBodies {
+		if body, ok := peekBody(resp); ok {
+			attrs = append(attrs, slog.String("response_body", redactBody(body)))
+		}
+	}
+
+	level := slog.LevelDebug
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	c.Logger.LogAttrs(ctx, level, "dockerhub request", attrs...)
+}
+
+// loggableBody reports whether a body of the given content type is text
+// worth logging, rather than an image layer or other blob.
+func loggableBody(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "json") || strings.HasPrefix(contentType, "text/")
+}
+
+// peekRequestBody returns the body of req for logging, leaving the request
+// able to send it.
+func peekRequestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil || !loggableBody(req.Header.Get("Content-Type")) {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	b, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody))
+	return b
+}
+
+// peekBody returns the start of the body of resp for logging, replacing
+// the body so that it can still be read in full.
+func peekBody(resp *http.Response) ([]byte, bool) {
+	if !loggableBody(resp.Header.Get("Content-Type")) {
+		return nil, false
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), resp.Body), resp.Body}
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// redactBody returns a body for logging with the values of secret JSON
+// fields replaced.
+func redactBody(b []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			// Truncated JSON cannot be redacted safely.
+			return "(malformed or truncated JSON)"
+		}
+		if !utf8.Valid(b) {
+			return "(binary)"
+		}
+		return string(b)
+	}
+	redactValue(v)
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "(unloggable)"
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if redactedFields[strings.ToLower(k)] {
+				v[k] = "REDACTED"
+				continue
+			}
+			redactValue(field)
+		}
+	case []interface{}:
+		for _, e := range v {
+			redactValue(e)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package dockerhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// captureLogs points the client's logger at a buffer and returns a
+// function decoding the records logged so far.
+func captureLogs(client *Client) func() []map[string]interface{} {
+	buf := new(bytes.Buffer)
+	client.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	return func() []map[string]interface{} {
+		var records []map[string]interface{}
+		dec := json.NewDecoder(buf)
+		for dec.More() {
+			record := make(map[string]interface{})
+			if err := dec.Decode(&record); err != nil {
+				panic(err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+}
+
+func TestClient_Logger(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+	logs := captureLogs(client)
+	client.LogBodies = true
+
+	mux.HandleFunc("/users/login/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "179")
+		w.Write(mustJSONMarshal(&LoginResponse{Token: "secret-token"}))
+	})
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	if err := client.Auth.Login(context.Background(), "someone", "s3cret"); err != nil {
+		t.Fatalf("Auth.Login returned error: %v", err)
+	}
+	if client.AuthToken() != "secret-token" {
+		t.Errorf("token is %q; logging must not consume the response", client.AuthToken())
+	}
+	client.User.GetLoggedInUser(context.Background())
+
+	records := logs()
+	if len(records) != 2 {
+		t.Fatalf("logged %d records; want 2", len(records))
+	}
+
+	login := records[0]
+	for k, want := range map[string]interface{}{
+		"level":                 "DEBUG",
+		"method":                "POST",
+		"path":                  "/v2/users/login/",
+		"status":                float64(200),
+		"attempt":               float64(1),
+		"x-ratelimit-remaining": "179",
+	} {
+		if login[k] != want {
+			t.Errorf("login record has %s %v; want %v", k, login[k], want)
+		}
+	}
+	if _, ok := login["latency"]; !ok {
+		t.Error("login record has no latency")
+	}
+	for _, k := range []string{"request_body", "response_body"} {
+		body, _ := login[k].(string)
+		if !strings.Contains(body, "REDACTED") || strings.Contains(body, "s3cret") || strings.Contains(body, "secret-token") {
+			t.Errorf("login record has %s %q; want secrets redacted", k, body)
+		}
+	}
+
+	if user := records[1]; user["level"] != "WARN" || user["status"] != float64(404) {
+		t.Errorf("failed request record is %v; want a warning with status 404", user)
+	}
+}
+
+func TestClient_Logger_RetryAttempt(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+	logs := captureLogs(client)
+
+	stale := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mustJSONMarshal(map[string]interface{}{"token": "stale"}))
+	}))
+	defer stale.Close()
+	client.RegistryAuthURL, _ = url.Parse(stale.URL)
+
+	digest := digestOf([]byte("blob"))
+	mux.HandleFunc("/v2/library/ubuntu/blobs/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("blob"))
+	})
+	if _, err := client.Registry.GetBlob(context.Background(), "", "ubuntu", digest); err != nil {
+		t.Fatalf("Registry.GetBlob returned error: %v", err)
+	}
+
+	var attempts []float64
+	for _, r := range logs() {
+		if strings.HasSuffix(r["path"].(string), digest) {
+			attempts = append(attempts, r["attempt"].(float64))
+		}
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("blob requests logged as attempts %v; want [1 2]", attempts)
+	}
+}
@@ -0,0 +1,282 @@
+package dockerhub
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Media types of the manifests and configs served by the registry.
+const (
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIConfig          = "application/vnd.oci.image.config.v1+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerConfig       = "application/vnd.docker.container.image.v1+json"
+)
+
+// manifestAccept lists every manifest media type this client understands.
+var manifestAccept = strings.Join([]string{
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+}, ", ")
+
+// Platform describes the platform an image manifest was built for.
+type Platform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+}
+
+// String returns the platform in os/arch[/variant] form.
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Descriptor references content in a registry by digest.
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	URLs         []string          `json:"urls,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+}
+
+// Manifest represents an OCI or Docker image manifest.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Index represents an OCI image index or Docker manifest list.
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ManifestResponse is a manifest fetched from the registry. Exactly one
+// of Manifest and Index is set, depending on MediaType.
+type ManifestResponse struct {
+	MediaType string
+	Digest    string
+	Raw       []byte
+
+	Manifest *Manifest
+	Index    *Index
+}
+
+// IsIndex reports whether the manifest is an index of other manifests.
+func (m *ManifestResponse) IsIndex() bool {
+	return m.Index != nil
+}
+
+// ImageConfig represents the config blob of an image.
+type ImageConfig struct {
+	Architecture string     `json:"architecture"`
+	OS           string     `json:"os"`
+	OSVersion    string     `json:"os.version,omitempty"`
+	Variant      string     `json:"variant,omitempty"`
+	Created      *time.Time `json:"created,omitempty"`
+	Author       string     `json:"author,omitempty"`
+
+	Config  ContainerConfig `json:"config"`
+	RootFS  RootFS          `json:"rootfs"`
+	History []History       `json:"history,omitempty"`
+}
+
+// ContainerConfig holds the defaults for containers run from an image.
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+// RootFS lists the uncompressed digests of an image's layers.
+type RootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// History describes the step which produced a layer of an image.
+type History struct {
+	Created    *time.Time `json:"created,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	EmptyLayer bool       `json:"empty_layer,omitempty"`
+}
+
+// isIndexMediaType reports whether mediaType is a manifest index type.
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == MediaTypeOCIIndex || mediaType == MediaTypeDockerManifestList
+}
+
+// digestOf returns the sha256 digest of b.
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// verifyDigest checks that b matches a sha256 digest.
+func verifyDigest(digest string, b []byte) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil
+	}
+	if got := digestOf(b); got != digest {
+		return fmt.Errorf("digest mismatch: got %s; want %s", got, digest)
+	}
+	return nil
+}
+
+// parseManifest decodes a raw manifest of any supported media type.
+func parseManifest(mediaType, digest string, raw []byte) (*ManifestResponse, error) {
+	var probe struct {
+		MediaType string          `json:"mediaType"`
+		Manifests json.RawMessage `json:"manifests"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, err
+	}
+
+	// Prefer the media type declared in the manifest over the header,
+	// which some registries report as plain JSON.
+	if probe.MediaType != "" {
+		mediaType = probe.MediaType
+	} else if probe.Manifests != nil {
+		mediaType = MediaTypeOCIIndex
+	} else if mediaType == "" || !strings.HasPrefix(mediaType, "application/vnd.") {
+		mediaType = MediaTypeOCIManifest
+	}
+
+	res := &ManifestResponse{MediaType: mediaType, Digest: digest, Raw: raw}
+	if isIndexMediaType(mediaType) {
+		res.Index = &Index{}
+		if err := json.Unmarshal(raw, res.Index); err != nil {
+			return nil, err
+		}
+	} else {
+		res.Manifest = &Manifest{}
+		if err := json.Unmarshal(raw, res.Manifest); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// GetManifest gets the manifest of a repository by tag or digest. Indexes
+// and manifest lists are returned as is, without resolving a platform.
+func (s *RegistryService) GetManifest(ctx context.Context, namespace, repo, reference string) (*ManifestResponse, error) {
+	ctx = withOperation(ctx, "Registry.GetManifest", namespace, repo)
+	name := repositoryName(namespace, repo)
+	req, err := s.newRequest(http.MethodGet, name, "/manifests/"+reference, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestAccept)
+
+	resp, err := s.do(ctx, req, repositoryScope(name, "pull"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = digestOf(raw)
+	}
+	if strings.HasPrefix(reference, "sha256:") {
+		if err := verifyDigest(reference, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseManifest(resp.Header.Get("Content-Type"), digest, raw)
+}
+
+// maxBlobSize is the largest blob GetBlob reads into memory. Larger
+// blobs, such as image layers, are copied with Copy instead.
+var maxBlobSize int64 = 64 << 20
+
+// GetBlob gets a blob of a repository by digest. The content is checked
+// against the digest.
+func (s *RegistryService) GetBlob(ctx context.Context, namespace, repo, digest string) ([]byte, error) {
+	ctx = withOperation(ctx, "Registry.GetBlob", namespace, repo)
+	name := repositoryName(namespace, repo)
+	req, err := s.newRequest(http.MethodGet, name, "/blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, req, repositoryScope(name, "pull"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.ContentLength > maxBlobSize {
+		return nil, fmt.Errorf("blob %s is %d bytes, larger than %d", digest, resp.ContentLength, maxBlobSize)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > maxBlobSize {
+		return nil, fmt.Errorf("blob %s is larger than %d bytes", digest, maxBlobSize)
+	}
+	if err := verifyDigest(digest, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetImageConfig gets and decodes the config blob of an image.
+func (s *RegistryService) GetImageConfig(ctx context.Context, namespace, repo, digest string) (*ImageConfig, error) {
+	ctx = withOperation(ctx, "Registry.GetImageConfig", namespace, repo)
+	b, err := s.GetBlob(ctx, namespace, repo, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImageConfig{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package dockerhub
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryService_GetManifest(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	index := &Index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIIndex,
+		Manifests: []Descriptor{{
+			MediaType: MediaTypeOCIManifest,
+			Digest:    "sha256:amd64",
+			Size:      100,
+			Platform:  &Platform{OS: "linux", Architecture: "amd64"},
+		}},
+	}
+	raw := mustJSONMarshal(index)
+
+	mux.HandleFunc("/v2/library/ubuntu/manifests/latest", func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		if got := r.Header.Get("Accept"); got != manifestAccept {
+			t.Errorf("Accept is %s; want %s", got, manifestAccept)
+		}
+		w.Header().Set("Content-Type", MediaTypeOCIIndex)
+		w.Write(raw)
+	})
+
+	res, err := client.Registry.GetManifest(context.Background(), "library", "ubuntu", "latest")
+	if err != nil {
+		t.Fatalf("Registry.GetManifest returned error: %v", err)
+	}
+
+	if !res.IsIndex() {
+		t.Fatalf("manifest is not an index")
+	}
+	if !reflect.DeepEqual(res.Index, index) {
+		t.Errorf("index is %v; want %v", res.Index, index)
+	}
+	if got, want := res.Digest, digestOf(raw); got != want {
+		t.Errorf("digest is %s; want %s", got, want)
+	}
+}
+
+func TestRegistryService_GetManifest_DockerManifest(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	manifest := &Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeDockerManifest,
+		Config:        Descriptor{MediaType: MediaTypeDockerConfig, Digest: "sha256:config", Size: 10},
+		Layers:        []Descriptor{{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Digest: "sha256:layer", Size: 20}},
+	}
+	raw := mustJSONMarshal(manifest)
+	digest := digestOf(raw)
+
+	mux.HandleFunc("/v2/someone/app/manifests/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeDockerManifest)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Write(raw)
+	})
+
+	res, err := client.Registry.GetManifest(context.Background(), "someone", "app", digest)
+	if err != nil {
+		t.Fatalf("Registry.GetManifest returned error: %v", err)
+	}
+
+	if res.IsIndex() {
+		t.Fatalf("manifest is an index")
+	}
+	if !reflect.DeepEqual(res.Manifest, manifest) {
+		t.Errorf("manifest is %v; want %v", res.Manifest, manifest)
+	}
+}
+
+func TestRegistryService_GetImageConfig(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	config := &ImageConfig{
+		Architecture: "amd64",
+		OS:           "linux",
+		Config: ContainerConfig{
+			Env: []string{"PATH=/usr/bin"},
+			Cmd: []string{"/bin/bash"},
+		},
+		RootFS: RootFS{Type: "layers", DiffIDs: []string{"sha256:diff"}},
+	}
+	raw := mustJSONMarshal(config)
+	digest := digestOf(raw)
+
+	mux.HandleFunc("/v2/library/ubuntu/blobs/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(raw)
+	})
+
+	res, err := client.Registry.GetImageConfig(context.Background(), "library", "ubuntu", digest)
+	if err != nil {
+		t.Fatalf("Registry.GetImageConfig returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, config) {
+		t.Errorf("config is %v; want %v", res, config)
+	}
+}
+
+func TestRegistryService_GetBlob_DigestMismatch(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	digest := digestOf([]byte("expected"))
+	mux.HandleFunc("/v2/library/ubuntu/blobs/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered"))
+	})
+
+	if _, err := client.Registry.GetBlob(context.Background(), "library", "ubuntu", digest); err == nil {
+		t.Errorf("Registry.GetBlob succeeded with mismatched content")
+	}
+}
+
+func TestRegistryService_GetBlob_TooLarge(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	defer func(size int64) { maxBlobSize = size }(maxBlobSize)
+	maxBlobSize = 4
+
+	digest := digestOf([]byte("too large"))
+	mux.HandleFunc("/v2/library/ubuntu/blobs/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("too large"))
+	})
+
+	_, err := client.Registry.GetBlob(context.Background(), "library", "ubuntu", digest)
+	if want := "blob " + digest + " is 9 bytes, larger than 4"; err == nil || err.Error() != want {
+		t.Errorf("Registry.GetBlob error is %v; want %s", err, want)
+	}
+}
@@ -0,0 +1,38 @@
+package dockerhub
+
+import "net/http"
+
+// A Doer sends HTTP requests. *http.Client is a Doer.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the Doer requests are sent with, to add behaviour
+// around every request the client sends, such as headers, metrics or
+// caching. The context of a request carries its Operation.
+type Middleware func(next Doer) Doer
+
+// Use adds middleware around the requests sent by the client, for both
+// the Dockerhub and registry APIs. Middleware runs in the order it is
+// added: the first is the outermost and sees requests first and responses
+// last. Use must not be called while requests are being sent.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// doer returns the chain of middleware ending with the HTTP client.
+func (c *Client) doer() Doer {
+	var d Doer = DoerFunc(c.roundTrip)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		d = c.middleware[i](d)
+	}
+	return d
+}
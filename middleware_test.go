@@ -0,0 +1,75 @@
+package dockerhub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClient_Use(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Request-Id"); got != "abc" {
+			t.Errorf("X-Request-Id is %q; want abc", got)
+		}
+		w.Write([]byte(`{"username":"someone"}`))
+	})
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next.Do(req)
+				calls = append(calls, name+" after")
+				return resp, err
+			})
+		}
+	}
+	client.Use(trace("outer"), func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			op, _ := OperationFromContext(req.Context())
+			calls = append(calls, op.Name)
+			req.Header.Set("X-Request-Id", "abc")
+			return next.Do(req)
+		})
+	})
+	client.Use(trace("inner"))
+
+	if _, err := client.User.GetLoggedInUser(context.Background()); err != nil {
+		t.Fatalf("User.GetLoggedInUser returned error: %v", err)
+	}
+	want := []string{"outer before", "User.GetLoggedInUser", "inner before", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("middleware ran as %v; want %v", calls, want)
+	}
+}
+
+func TestClient_Use_ShortCircuit(t *testing.T) {
+	client, _, teardown := makeMockClient()
+	defer teardown()
+
+	client.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(`{"username":"cached"}`)),
+				Request:    req,
+			}, nil
+		})
+	})
+
+	user, err := client.User.GetLoggedInUser(context.Background())
+	if err != nil {
+		t.Fatalf("User.GetLoggedInUser returned error: %v", err)
+	}
+	if user.Username != "cached" {
+		t.Errorf("username is %q; want the middleware's response", user.Username)
+	}
+}
@@ -0,0 +1,36 @@
+package dockerhub
+
+import "context"
+
+// Operation describes the client method a request is sent for, so that
+// transports and middleware can label what they observe, for example as
+// the names of trace spans.
+type Operation struct {
+	// Name is the service and method, such as
+	// "Repositories.GetRepository".
+	Name string
+
+	// Namespace and Repository are the repository the method was called
+	// for, if any.
+	Namespace  string
+	Repository string
+}
+
+type operationKey struct{}
+
+// withOperation returns a context in which requests are made for the
+// named operation. Methods implemented with other methods keep the
+// operation they were called as.
+func withOperation(ctx context.Context, name, namespace, repo string) context.Context {
+	if _, ok := OperationFromContext(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, operationKey{}, Operation{Name: name, Namespace: namespace, Repository: repo})
+}
+
+// OperationFromContext returns the operation a request is made for, from
+// the context of the request.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
@@ -51,6 +51,7 @@ type OrganizationList struct {
 
 // CreateOrganization Create new Organization
 func (s *OrganizationService) CreateOrganization(ctx context.Context, organization, company string) (*Organization, error) {
+	ctx = withOperation(ctx, "Organization.CreateOrganization", organization, "")
 	url := "/orgs/"
 	org := CreateOrganizationRequest{
 		Orgname: organization,
@@ -73,6 +74,7 @@ func (s *OrganizationService) CreateOrganization(ctx context.Context, organizati
 
 // GetOrganizations all organizations of user
 func (s *OrganizationService) GetOrganizations(ctx context.Context, pageSize int) (*OrganizationList, error) {
+	ctx = withOperation(ctx, "Organization.GetOrganizations", "", "")
 	slug := fmt.Sprintf("/user/orgs/?page_size=%d", pageSize)
 	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
 	if err != nil {
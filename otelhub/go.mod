@@ -0,0 +1,25 @@
+module github.com/ErKiran/dockerhub-go/otelhub
+
+go 1.21
+
+require (
+	github.com/ErKiran/dockerhub-go v0.1.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/sdk/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
+// Build against the module in this repository, so that changes to both
+// can be made together. Others get the requirement above, which must name
+// a released version of the root module: tag the root first, update the
+// requirement to that tag, and only then tag otelhub/vX.Y.Z.
+replace github.com/ErKiran/dockerhub-go => ../
@@ -0,0 +1,164 @@
+// Package otelhub instruments the dockerhub client with OpenTelemetry.
+//
+// It is a separate module so that the client does not depend on
+// OpenTelemetry. Its Transport wraps the transport of the http.Client
+// given to dockerhub.NewClient:
+//
+//	transport, err := otelhub.NewTransport(nil)
+//	client := dockerhub.NewClient(&http.Client{Transport: transport})
+//
+// Each request gets a client span named after the method it is sent for,
+// such as "Repositories.GetRepository", and is counted in the request,
+// latency and rate limit metrics.
+package otelhub
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer and meter of this package.
+const instrumentationName = "github.com/ErKiran/dockerhub-go/otelhub"
+
+// Attribute keys of the spans and metrics recorded, besides the HTTP
+// semantic conventions.
+const (
+	OperationKey  = attribute.Key("dockerhub.operation")
+	NamespaceKey  = attribute.Key("dockerhub.namespace")
+	RepositoryKey = attribute.Key("dockerhub.repository")
+)
+
+// config holds the options of a Transport.
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// An Option configures a Transport.
+type Option func(*config)
+
+// WithTracerProvider sets the provider of the tracer spans are started
+// with, instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider sets the provider of the meter metrics are recorded
+// with, instead of the global one.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = mp }
+}
+
+// Transport is an http.RoundTripper tracing and measuring the requests of
+// a dockerhub client.
+type Transport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+
+	requests    metric.Int64Counter
+	duration    metric.Float64Histogram
+	rateLimited metric.Int64Counter
+}
+
+// NewTransport returns a Transport sending requests with base, or
+// http.DefaultTransport if it is nil.
+func NewTransport(base http.RoundTripper, opts ...Option) (*Transport, error) {
+	cfg := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	t := &Transport{
+		base:   base,
+		tracer: cfg.tracerProvider.Tracer(instrumentationName),
+	}
+
+	var err error
+	t.requests, err = meter.Int64Counter("dockerhub.client.requests",
+		metric.WithDescription("Requests sent to Dockerhub."),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	t.duration, err = meter.Float64Histogram("dockerhub.client.request.duration",
+		metric.WithDescription("Time until the response headers of Dockerhub requests were received."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	t.rateLimited, err = meter.Int64Counter("dockerhub.client.rate_limited",
+		metric.WithDescription("Requests Dockerhub rejected with 429 Too Many Requests."),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RoundTrip sends req within a span and records its metrics.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	name := "HTTP " + req.Method
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+	}
+	if op, ok := dockerhub.OperationFromContext(ctx); ok {
+		name = op.Name
+		attrs = append(attrs, OperationKey.String(op.Name))
+		if op.Namespace != "" {
+			attrs = append(attrs, NamespaceKey.String(op.Namespace))
+		}
+		if op.Repository != "" {
+			attrs = append(attrs, RepositoryKey.String(op.Repository))
+		}
+	}
+
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(attribute.String("url.path", req.URL.Path)))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	elapsed := time.Since(start).Seconds()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		attrs = append(attrs, attribute.String("error.type", "transport"))
+	} else {
+		status := attribute.Int("http.response.status_code", resp.StatusCode)
+		span.SetAttributes(status)
+		attrs = append(attrs, status)
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			attrs = append(attrs, attribute.String("error.type", strconv.Itoa(resp.StatusCode)))
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.rateLimited.Add(ctx, 1, metric.WithAttributes(attrs...))
+		}
+	}
+
+	set := metric.WithAttributes(attrs...)
+	t.requests.Add(ctx, 1, set)
+	t.duration.Record(ctx, elapsed, set)
+	return resp, err
+}
@@ -0,0 +1,99 @@
+package otelhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	dockerhub "github.com/ErKiran/dockerhub-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/repositories/someone/app/" {
+			w.Write([]byte(`{"name":"app","namespace":"someone"}`))
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	transport, err := NewTransport(nil,
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	if err != nil {
+		t.Fatalf("NewTransport returned error: %v", err)
+	}
+
+	client := dockerhub.NewClient(&http.Client{Transport: transport})
+	client.BaseURL, _ = url.Parse(srv.URL)
+	ctx := context.Background()
+	if _, err := client.Repositories.GetRepository(ctx, "someone", "app"); err != nil {
+		t.Fatalf("Repositories.GetRepository returned error: %v", err)
+	}
+	if _, err := client.Tag.GetTags(ctx, "someone", "app", 10); err == nil {
+		t.Fatal("Tag.GetTags returned no error for a 429")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("recorded %d spans; want 2", len(ended))
+	}
+	get := ended[0]
+	if get.Name() != "Repositories.GetRepository" {
+		t.Errorf("span name is %q; want Repositories.GetRepository", get.Name())
+	}
+	attrs := attribute.NewSet(get.Attributes()...)
+	for k, want := range map[attribute.Key]attribute.Value{
+		NamespaceKey:                attribute.StringValue("someone"),
+		RepositoryKey:               attribute.StringValue("app"),
+		"http.response.status_code": attribute.IntValue(200),
+		"http.request.method":       attribute.StringValue("GET"),
+	} {
+		if got, ok := attrs.Value(k); !ok || got != want {
+			t.Errorf("span attribute %s is %v; want %v", k, got.Emit(), want.Emit())
+		}
+	}
+	if tags := ended[1]; tags.Name() != "Tag.GetTags" || tags.Status().Code != codes.Error {
+		t.Errorf("span of 429 is %s with status %v; want Tag.GetTags with an error", tags.Name(), tags.Status())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	sums := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					sums[m.Name] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					sums[m.Name] += int64(dp.Count)
+				}
+			}
+		}
+	}
+	for name, want := range map[string]int64{
+		"dockerhub.client.requests":         2,
+		"dockerhub.client.request.duration": 2,
+		"dockerhub.client.rate_limited":     1,
+	} {
+		if sums[name] != want {
+			t.Errorf("metric %s is %d; want %d", name, sums[name], want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PinnedImage is a base image named by a FROM instruction.
+type PinnedImage struct {
+	// Line is the 1-based line number of the FROM instruction.
+	Line int
+
+	// Image is the image as written in the Dockerfile.
+	Image string
+
+	// Pinned is the image in image:tag@sha256:... form, and Digest the
+	// digest it was resolved to. Both are empty for skipped images.
+	Pinned string
+	Digest string
+
+	// Skipped explains why an image was left as written, for example
+	// because it is a build stage or is not hosted on Dockerhub.
+	Skipped string
+}
+
+// PinReport lists the base images of a Dockerfile and holds the
+// Dockerfile rewritten to use the pinned references.
+type PinReport struct {
+	Images     []PinnedImage
+	Dockerfile []byte
+}
+
+// dockerfileWord is a word of a Dockerfile instruction, with its place in
+// the Dockerfile so that it can be replaced.
+type dockerfileWord struct {
+	text       string
+	line       int
+	start, end int
+}
+
+// parserDirectiveRegexp matches a parser directive such as "# escape=`".
+var parserDirectiveRegexp = regexp.MustCompile(`^#\s*([a-zA-Z][a-zA-Z0-9]*)\s*=\s*(.+?)\s*$`)
+
+// heredocRegexp matches the start of a heredoc, such as <<EOF or <<-"EOF".
+var heredocRegexp = regexp.MustCompile(`^<<(-?)(["']?)([a-zA-Z_][a-zA-Z0-9_]*)(["']?)$`)
+
+// knownDirectives are the parser directives Docker recognizes. Any other
+// comment ends the directives at the top of a Dockerfile.
+var knownDirectives = map[string]bool{"syntax": true, "escape": true, "check": true}
+
+// dockerfileParser splits a Dockerfile into instructions, joining lines
+// continued with the escape character and skipping comments and heredoc
+// bodies.
+type dockerfileParser struct {
+	lines      []string
+	next       int
+	escape     byte
+	directives bool
+}
+
+func newDockerfileParser(dockerfile []byte) *dockerfileParser {
+	return &dockerfileParser{
+		lines:      strings.SplitAfter(string(dockerfile), "\n"),
+		escape:     '\\',
+		directives: true,
+	}
+}
+
+// text returns line n without its line ending.
+func (p *dockerfileParser) text(n int) string {
+	return strings.TrimRight(p.lines[n], "\r\n")
+}
+
+// blank reports whether line n is empty or a comment, which Docker skips
+// within an instruction.
+func (p *dockerfileParser) blank(n int) bool {
+	trimmed := strings.TrimSpace(p.text(n))
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// instruction returns the words of the next instruction and the 1-based
+// line it starts on, or false at the end of the Dockerfile.
+func (p *dockerfileParser) instruction() ([]dockerfileWord, int, bool) {
+	for ; p.next < len(p.lines); p.next++ {
+		trimmed := strings.TrimSpace(p.text(p.next))
+		if p.directives {
+			if m := parserDirectiveRegexp.FindStringSubmatch(trimmed); m != nil && knownDirectives[strings.ToLower(m[1])] {
+				if strings.EqualFold(m[1], "escape") && (m[2] == "\\" || m[2] == "`") {
+					p.escape = m[2][0]
+				}
+				continue
+			}
+			p.directives = false
+		}
+		if !p.blank(p.next) {
+			break
+		}
+	}
+	if p.next >= len(p.lines) {
+		return nil, 0, false
+	}
+
+	first := p.next
+	var words []dockerfileWord
+	for p.next < len(p.lines) {
+		line := p.text(p.next)
+		body := strings.TrimRight(line, " \t")
+		continued := strings.HasSuffix(body, string(p.escape))
+		if continued {
+			body = body[:len(body)-1]
+		}
+		words = append(words, splitWords(body, p.next)...)
+		p.next++
+		if !continued {
+			break
+		}
+		for p.next < len(p.lines) && p.blank(p.next) {
+			p.next++
+		}
+	}
+
+	if len(words) > 0 {
+		switch strings.ToUpper(words[0].text) {
+		case "RUN", "COPY", "ADD":
+			p.skipHeredocs(words[1:])
+		}
+	}
+	return words, first + 1, true
+}
+
+// skipHeredocs skips the bodies of the heredocs started in words.
+func (p *dockerfileParser) skipHeredocs(words []dockerfileWord) {
+	for _, w := range words {
+		m := heredocRegexp.FindStringSubmatch(w.text)
+		if m == nil || m[2] != m[4] {
+			continue
+		}
+		for ; p.next < len(p.lines); p.next++ {
+			line := p.text(p.next)
+			if m[1] == "-" {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == m[3] {
+				p.next++
+				break
+			}
+		}
+	}
+}
+
+// splitWords splits the text of line n at whitespace.
+func splitWords(text string, n int) []dockerfileWord {
+	var words []dockerfileWord
+	start := -1
+	for i := 0; i <= len(text); i++ {
+		space := i == len(text) || text[i] == ' ' || text[i] == '\t'
+		switch {
+		case space && start >= 0:
+			words = append(words, dockerfileWord{text: text[start:i], line: n, start: start, end: i})
+			start = -1
+		case !space && start < 0:
+			start = i
+		}
+	}
+	return words
+}
+
+// PinDockerfile resolves each base image named by a FROM instruction in
+// dockerfile to the digest its tag currently points to, and returns the
+// images along with the Dockerfile rewritten to reference them as
+// image:tag@sha256:.... Images which are already pinned, refer to an
+// earlier build stage, use build arguments, live on another registry or
+// cannot be resolved are left as written.
+func (s *RegistryService) PinDockerfile(ctx context.Context, dockerfile []byte) (*PinReport, error) {
+	ctx = withOperation(ctx, "Registry.PinDockerfile", "", "")
+	res := &PinReport{}
+	stages := make(map[string]bool)
+
+	p := newDockerfileParser(dockerfile)
+	for {
+		words, n, ok := p.instruction()
+		if !ok {
+			break
+		}
+		if !strings.EqualFold(words[0].text, "FROM") {
+			continue
+		}
+
+		// Skip flags such as --platform to find the image.
+		i := 1
+		for i < len(words) && strings.HasPrefix(words[i].text, "--") {
+			i++
+		}
+		if i == len(words) {
+			continue
+		}
+		from := words[i]
+
+		img := PinnedImage{Line: n, Image: from.text}
+		switch ref, err := ParseReference(from.text); {
+		case strings.EqualFold(from.text, "scratch"):
+			img.Skipped = "scratch is not an image"
+		case stages[strings.ToLower(from.text)]:
+			img.Skipped = "refers to a build stage"
+		case strings.Contains(from.text, "$"):
+			img.Skipped = "uses a build argument"
+		case err != nil:
+			img.Skipped = err.Error()
+		case ref.Digest != "":
+			img.Skipped = "already pinned"
+		default:
+			digest, err := s.ResolveDigest(ctx, ref.Namespace, ref.Repository, ref.Identifier())
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				img.Skipped = fmt.Sprintf("cannot resolve digest: %v", err)
+				break
+			}
+
+			img.Digest = digest
+			img.Pinned = from.text
+			if ref.Tag == "" {
+				img.Pinned += ":latest"
+			}
+			img.Pinned += "@" + digest
+
+			// Replace just the image, so that the rest of the line, such
+			// as indentation and comments, survives.
+			line := p.lines[from.line]
+			p.lines[from.line] = line[:from.start] + img.Pinned + line[from.end:]
+		}
+
+		if i+2 < len(words) && strings.EqualFold(words[i+1].text, "AS") {
+			stages[strings.ToLower(words[i+2].text)] = true
+		}
+		res.Images = append(res.Images, img)
+	}
+
+	res.Dockerfile = []byte(strings.Join(p.lines, ""))
+	return res, nil
+}
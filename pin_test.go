@@ -0,0 +1,133 @@
+package dockerhub
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistryService_PinDockerfile(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	golang := reg.pushImage("library/golang", "1.21", Platform{OS: "linux", Architecture: "amd64"}, "golang")
+	alpine := reg.pushImage("library/alpine", "latest", Platform{OS: "linux", Architecture: "amd64"}, "alpine")
+	tool := reg.pushImage("myorg/tool", "2", Platform{OS: "linux", Architecture: "amd64"}, "tool")
+
+	dockerfile := `ARG BASE=alpine
+FROM --platform=$BUILDPLATFORM golang:1.21 AS build
+RUN go build ./...
+
+  from myorg/tool:2 as tools
+FROM build AS test
+FROM ghcr.io/someone/thing:1
+FROM ${BASE}
+FROM alpine
+FROM ubuntu@sha256:0123
+FROM scratch
+`
+
+	res, err := client.Registry.PinDockerfile(context.Background(), []byte(dockerfile))
+	if err != nil {
+		t.Fatalf("Registry.PinDockerfile returned error: %v", err)
+	}
+
+	want := `ARG BASE=alpine
+FROM --platform=$BUILDPLATFORM golang:1.21@` + golang.Digest + ` AS build
+RUN go build ./...
+
+  from myorg/tool:2@` + tool.Digest + ` as tools
+FROM build AS test
+FROM ghcr.io/someone/thing:1
+FROM ${BASE}
+FROM alpine:latest@` + alpine.Digest + `
+FROM ubuntu@sha256:0123
+FROM scratch
+`
+	if got := string(res.Dockerfile); got != want {
+		t.Errorf("Dockerfile is\n%s\nwant\n%s", got, want)
+	}
+
+	images := []PinnedImage{
+		{Line: 2, Image: "golang:1.21", Pinned: "golang:1.21@" + golang.Digest, Digest: golang.Digest},
+		{Line: 5, Image: "myorg/tool:2", Pinned: "myorg/tool:2@" + tool.Digest, Digest: tool.Digest},
+		{Line: 6, Image: "build", Skipped: "refers to a build stage"},
+		{Line: 7, Image: "ghcr.io/someone/thing:1", Skipped: `reference "ghcr.io/someone/thing:1" is not on Dockerhub`},
+		{Line: 8, Image: "${BASE}", Skipped: "uses a build argument"},
+		{Line: 9, Image: "alpine", Pinned: "alpine:latest@" + alpine.Digest, Digest: alpine.Digest},
+		{Line: 10, Image: "ubuntu@sha256:0123", Skipped: "already pinned"},
+		{Line: 11, Image: "scratch", Skipped: "scratch is not an image"},
+	}
+	if !reflect.DeepEqual(res.Images, images) {
+		t.Errorf("images are %+v; want %+v", res.Images, images)
+	}
+}
+
+func TestRegistryService_PinDockerfile_Syntax(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	alpine := reg.pushImage("library/alpine", "3", Platform{OS: "linux", Architecture: "amd64"}, "alpine")
+	golang := reg.pushImage("library/golang", "1.21", Platform{OS: "linux", Architecture: "amd64"}, "golang")
+
+	for _, tc := range []struct {
+		name       string
+		dockerfile string
+		want       string
+		line       int
+	}{
+		{
+			"continuation",
+			"FROM \\\n  # a comment\n  --platform=linux/amd64 \\\n  alpine:3 AS base\n",
+			"FROM \\\n  # a comment\n  --platform=linux/amd64 \\\n  alpine:3@" + alpine.Digest + " AS base\n",
+			1,
+		},
+		{
+			"escape directive",
+			"# syntax=docker/dockerfile:1\n# escape=`\nRUN dir C:\\\nFROM `\n  alpine:3\n",
+			"# syntax=docker/dockerfile:1\n# escape=`\nRUN dir C:\\\nFROM `\n  alpine:3@" + alpine.Digest + "\n",
+			4,
+		},
+		{
+			"heredoc",
+			"FROM golang:1.21\nRUN <<EOF\nFROM alpine:3\nEOF\nCOPY <<-\"END\" /etc/motd\n\tFROM alpine:3\n\tEND\n",
+			"FROM golang:1.21@" + golang.Digest + "\nRUN <<EOF\nFROM alpine:3\nEOF\nCOPY <<-\"END\" /etc/motd\n\tFROM alpine:3\n\tEND\n",
+			1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := client.Registry.PinDockerfile(context.Background(), []byte(tc.dockerfile))
+			if err != nil {
+				t.Fatalf("Registry.PinDockerfile returned error: %v", err)
+			}
+			if got := string(res.Dockerfile); got != tc.want {
+				t.Errorf("Dockerfile is\n%s\nwant\n%s", got, tc.want)
+			}
+			if len(res.Images) != 1 || res.Images[0].Line != tc.line || res.Images[0].Skipped != "" {
+				t.Errorf("images are %+v; want one pinned on line %d", res.Images, tc.line)
+			}
+		})
+	}
+}
+
+func TestRegistryService_PinDockerfile_Unresolvable(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	alpine := reg.pushImage("library/alpine", "3", Platform{OS: "linux", Architecture: "amd64"}, "alpine")
+
+	res, err := client.Registry.PinDockerfile(context.Background(), []byte("FROM missing:1\nFROM alpine:3\n"))
+	if err != nil {
+		t.Fatalf("Registry.PinDockerfile returned error: %v", err)
+	}
+	if len(res.Images) != 2 || !strings.HasPrefix(res.Images[0].Skipped, "cannot resolve digest: ") || res.Images[1].Digest != alpine.Digest {
+		t.Errorf("images are %+v; want missing skipped and alpine pinned", res.Images)
+	}
+	if want := "FROM missing:1\nFROM alpine:3@" + alpine.Digest + "\n"; string(res.Dockerfile) != want {
+		t.Errorf("Dockerfile is\n%s\nwant\n%s", res.Dockerfile, want)
+	}
+}
@@ -0,0 +1,84 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// rateLimitRepository is the repository Docker provides for checking
+// pull limits. A HEAD request for its manifest does not count as a pull.
+const rateLimitRepository = "ratelimitpreview/test"
+
+// PullRateLimit is the pull rate limit applied to the credentials set
+// with SetRegistryAuth, or to the client's IP address without them.
+type PullRateLimit struct {
+	// Limited is false when the registry reports no limit, as for paid
+	// accounts; the other fields are then zero.
+	Limited bool
+
+	Limit     int
+	Remaining int
+
+	// Window is the length of the period the limit applies to, in
+	// seconds.
+	Window int
+
+	// Source is the account ID or IP address the limit is tracked for.
+	Source string
+}
+
+// parseRateLimitHeader parses a header value such as "100;w=21600" into
+// its count and window.
+func parseRateLimitHeader(v string) (count, window int, err error) {
+	parts := strings.Split(v, ";")
+	if count, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
+		return 0, 0, fmt.Errorf("invalid rate limit %q", v)
+	}
+	for _, p := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if key != "w" {
+			continue
+		}
+		if window, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("invalid rate limit %q", v)
+		}
+	}
+	return count, window, nil
+}
+
+// RateLimitStatus reports the current pull rate limit without using up a
+// pull.
+func (s *RegistryService) RateLimitStatus(ctx context.Context) (*PullRateLimit, error) {
+	ctx = withOperation(ctx, "Registry.RateLimitStatus", "", "")
+	req, err := s.newRequest(http.MethodHead, rateLimitRepository, "/manifests/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestAccept)
+
+	resp, err := s.do(ctx, req, repositoryScope(rateLimitRepository, "pull"))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	res := &PullRateLimit{Source: resp.Header.Get("Docker-RateLimit-Source")}
+
+	limit := resp.Header.Get("RateLimit-Limit")
+	if limit == "" {
+		return res, nil
+	}
+	res.Limited = true
+	if res.Limit, res.Window, err = parseRateLimitHeader(limit); err != nil {
+		return nil, err
+	}
+	if remaining := resp.Header.Get("RateLimit-Remaining"); remaining != "" {
+		if res.Remaining, _, err = parseRateLimitHeader(remaining); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,60 @@
+package dockerhub
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryService_RateLimitStatus(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	mux.HandleFunc("/v2/ratelimitpreview/test/manifests/latest", func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodHead)
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		w.Header().Set("RateLimit-Remaining", "76;w=21600")
+		w.Header().Set("Docker-RateLimit-Source", "192.0.2.1")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	res, err := client.Registry.RateLimitStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Registry.RateLimitStatus returned error: %v", err)
+	}
+
+	want := &PullRateLimit{Limited: true, Limit: 100, Remaining: 76, Window: 21600, Source: "192.0.2.1"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("rate limit is %v; want %v", res, want)
+	}
+}
+
+func TestRegistryService_RateLimitStatus_Unlimited(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	mux.HandleFunc("/v2/ratelimitpreview/test/manifests/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	res, err := client.Registry.RateLimitStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Registry.RateLimitStatus returned error: %v", err)
+	}
+
+	if res.Limited {
+		t.Errorf("rate limit is %v; want unlimited", res)
+	}
+}
+
+func TestParseRateLimitHeader(t *testing.T) {
+	count, window, err := parseRateLimitHeader("200;w=21600")
+	if err != nil || count != 200 || window != 21600 {
+		t.Errorf("parseRateLimitHeader is %d, %d, %v; want 200, 21600, nil", count, window, err)
+	}
+
+	if _, _, err := parseRateLimitHeader("lots"); err == nil {
+		t.Errorf("parseRateLimitHeader succeeded on invalid header")
+	}
+}
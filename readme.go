@@ -0,0 +1,117 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxFullDescriptionLength is the longest full description, in
+// characters, that Dockerhub accepts for a repository.
+const MaxFullDescriptionLength = 25000
+
+// SyncReadmeOptions configures SyncReadme.
+type SyncReadmeOptions struct {
+	// GitHubRepository, in owner/name form, makes relative links and
+	// images absolute so that they keep working on Dockerhub. Links
+	// point to github.com and images to raw.githubusercontent.com.
+	GitHubRepository string
+
+	// GitHubRef is the branch, tag or commit links point to. Defaults
+	// to "main".
+	GitHubRef string
+
+	// GitHubDir is the directory of the README within the GitHub
+	// repository, against which relative links are resolved.
+	GitHubDir string
+}
+
+var (
+	markdownLinkRegexp = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)((?:\s+"[^"]*")?)\)`)
+	htmlLinkRegexp     = regexp.MustCompile(`(?i)\b(src|href)="([^"]+)"`)
+	schemeRegexp       = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// absoluteGitHubURL returns the GitHub URL for a link relative to the
+// README, or the link unchanged if it is not relative.
+func (o *SyncReadmeOptions) absoluteGitHubURL(link string, image bool) string {
+	if link == "" || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "//") || schemeRegexp.MatchString(link) {
+		return link
+	}
+
+	ref := o.GitHubRef
+	if ref == "" {
+		ref = "main"
+	}
+
+	p := link
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join("/", o.GitHubDir, p)
+	}
+	p = strings.TrimPrefix(path.Clean(p), "/")
+	if strings.HasSuffix(link, "/") {
+		p += "/"
+	}
+
+	if image {
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", o.GitHubRepository, ref, p)
+	}
+	return fmt.Sprintf("https://github.com/%s/blob/%s/%s", o.GitHubRepository, ref, p)
+}
+
+// rewriteLinks makes the relative Markdown and HTML links and images in
+// markdown absolute GitHub URLs.
+func (o *SyncReadmeOptions) rewriteLinks(markdown string) string {
+	markdown = markdownLinkRegexp.ReplaceAllStringFunc(markdown, func(m string) string {
+		sub := markdownLinkRegexp.FindStringSubmatch(m)
+		target := o.absoluteGitHubURL(sub[3], sub[1] == "!")
+		return fmt.Sprintf("%s[%s](%s%s)", sub[1], sub[2], target, sub[4])
+	})
+	return htmlLinkRegexp.ReplaceAllStringFunc(markdown, func(m string) string {
+		sub := htmlLinkRegexp.FindStringSubmatch(m)
+		target := o.absoluteGitHubURL(sub[2], strings.EqualFold(sub[1], "src"))
+		return fmt.Sprintf(`%s="%s"`, sub[1], target)
+	})
+}
+
+// SyncReadme sets the full description of a repository to the contents
+// of the Markdown file named filename. The file is rejected if it is empty, as
+// the API cannot clear a full description, or exceeds
+// MaxFullDescriptionLength, and the repository is only patched if its
+// description differs. It reports whether the repository was updated.
+func (s *RepositoriesService) SyncReadme(ctx context.Context, namespace, repo, filename string, opts *SyncReadmeOptions) (bool, error) {
+	ctx = withOperation(ctx, "Repositories.SyncReadme", namespace, repo)
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+
+	readme := string(b)
+	if strings.TrimSpace(readme) == "" {
+		return false, fmt.Errorf("%s is empty", filename)
+	}
+	if opts != nil && opts.GitHubRepository != "" {
+		readme = opts.rewriteLinks(readme)
+	}
+
+	if n := utf8.RuneCountInString(readme); n > MaxFullDescriptionLength {
+		return false, fmt.Errorf("%s is %d characters; Dockerhub allows at most %d", filename, n, MaxFullDescriptionLength)
+	}
+
+	current, err := s.GetRepository(ctx, namespace, repo)
+	if err != nil {
+		return false, err
+	}
+	if current.FullDescription == readme {
+		return false, nil
+	}
+
+	if _, err := s.EditRepository(ctx, namespace, repo, &RepositoryPatch{FullDescription: readme}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,109 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncReadmeOptions_rewriteLinks(t *testing.T) {
+	opts := &SyncReadmeOptions{GitHubRepository: "someone/app", GitHubDir: "docs"}
+
+	in := `# App
+See [the guide](guide.md "Guide"), [setup](../SETUP.md#linux), [root](/LICENSE) and [site](https://example.com).
+Jump to [usage](#usage). ![logo](img/logo.png)
+<img src="img/banner.png"> <a href="mailto:a@example.com">mail</a>
+`
+	want := `# App
+See [the guide](https://github.com/someone/app/blob/main/docs/guide.md "Guide"), [setup](https://github.com/someone/app/blob/main/SETUP.md#linux), [root](https://github.com/someone/app/blob/main/LICENSE) and [site](https://example.com).
+Jump to [usage](#usage). ![logo](https://raw.githubusercontent.com/someone/app/main/docs/img/logo.png)
+<img src="https://raw.githubusercontent.com/someone/app/main/docs/img/banner.png"> <a href="mailto:a@example.com">mail</a>
+`
+	if got := opts.rewriteLinks(in); got != want {
+		t.Errorf("rewriteLinks is\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRepositoriesService_SyncReadme(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		current string
+		changed bool
+	}{
+		{"changed", "old", true},
+		{"unchanged", "# Hello\n", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, mux, teardown := makeMockClient()
+			defer teardown()
+
+			readme := filepath.Join(t.TempDir(), "README.md")
+			if err := os.WriteFile(readme, []byte("# Hello\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			patched := false
+			mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					w.Write(mustJSONMarshal(&Repository{FullDescription: tc.current}))
+				case http.MethodPatch:
+					patched = true
+					assertBody(t, r, string(mustJSONMarshal(&RepositoryPatch{FullDescription: "# Hello\n"})))
+					w.Write(mustJSONMarshal(&Repository{FullDescription: "# Hello\n"}))
+				}
+			})
+
+			changed, err := client.Repositories.SyncReadme(context.Background(), "someone", "app", readme, nil)
+			if err != nil {
+				t.Fatalf("Repositories.SyncReadme returned error: %v", err)
+			}
+			if changed != tc.changed || patched != tc.changed {
+				t.Errorf("changed is %v, patched %v; want %v", changed, patched, tc.changed)
+			}
+		})
+	}
+}
+
+func TestRepositoriesService_SyncReadme_TooLong(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	readme := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(readme, []byte(strings.Repeat("é", MaxFullDescriptionLength+1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request", r.Method)
+	})
+
+	_, err := client.Repositories.SyncReadme(context.Background(), "someone", "app", readme, nil)
+	want := fmt.Sprintf("%s is %d characters; Dockerhub allows at most %d", readme, MaxFullDescriptionLength+1, MaxFullDescriptionLength)
+	if err == nil || err.Error() != want {
+		t.Errorf("Repositories.SyncReadme error is %v; want %s", err, want)
+	}
+}
+
+func TestRepositoriesService_SyncReadme_Empty(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	readme := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(readme, []byte("\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mux.HandleFunc("/repositories/someone/app/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request", r.Method)
+	})
+
+	changed, err := client.Repositories.SyncReadme(context.Background(), "someone", "app", readme, nil)
+	if want := readme + " is empty"; changed || err == nil || err.Error() != want {
+		t.Errorf("Repositories.SyncReadme returned %v, %v; want false, %s", changed, err, want)
+	}
+}
@@ -0,0 +1,177 @@
+package dockerhub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Artifact types given to the signatures, attestations and SBOMs found
+// through cosign's tag conventions, which carry no artifact type of
+// their own.
+const (
+	ArtifactTypeCosignSignature   = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	ArtifactTypeCosignAttestation = "application/vnd.dev.cosign.artifact.att.v1+json"
+	ArtifactTypeCosignSBOM        = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+)
+
+// cosignSuffixes maps the suffixes of cosign's sha256-<hex>.<suffix> tags
+// to the artifact type they hold.
+var cosignSuffixes = []struct {
+	suffix       string
+	artifactType string
+}{
+	{".sig", ArtifactTypeCosignSignature},
+	{".att", ArtifactTypeCosignAttestation},
+	{".sbom", ArtifactTypeCosignSBOM},
+}
+
+// isNotFound reports whether err is a 404 response.
+func isNotFound(err error) bool {
+	var errResp *ErrorResponse
+	return errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound
+}
+
+// ResolveDigest returns the digest of the manifest a tag or digest
+// refers to, without downloading it.
+func (s *RegistryService) ResolveDigest(ctx context.Context, namespace, repo, reference string) (string, error) {
+	ctx = withOperation(ctx, "Registry.ResolveDigest", namespace, repo)
+	if strings.HasPrefix(reference, "sha256:") {
+		return reference, nil
+	}
+
+	name := repositoryName(namespace, repo)
+	req, err := s.newRequest(http.MethodHead, name, "/manifests/"+reference, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", manifestAccept)
+
+	resp, err := s.do(ctx, req, repositoryScope(name, "pull"))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Fall back to hashing the manifest for registries which omit the
+	// digest header.
+	m, err := s.GetManifest(ctx, namespace, repo, reference)
+	if err != nil {
+		return "", err
+	}
+	return m.Digest, nil
+}
+
+// Referrers lists the artifacts, such as SBOMs, signatures and
+// attestations, attached to the image a tag or digest refers to. If
+// artifactType is not empty, only artifacts of that type are returned.
+// Registries without the OCI referrers API are searched using the
+// referrers tag schema and cosign's sha256-<hex>.sig, .att and .sbom
+// tags instead.
+func (s *RegistryService) Referrers(ctx context.Context, namespace, repo, reference, artifactType string) ([]Descriptor, error) {
+	ctx = withOperation(ctx, "Registry.Referrers", namespace, repo)
+	digest, err := s.ResolveDigest(ctx, namespace, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.referrersAPI(ctx, namespace, repo, digest, artifactType)
+	if isNotFound(err) {
+		res, err = s.referrersTags(ctx, namespace, repo, digest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if artifactType == "" {
+		return res, nil
+	}
+	filtered := make([]Descriptor, 0, len(res))
+	for _, d := range res {
+		if d.ArtifactType == artifactType {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered, nil
+}
+
+// referrersAPI queries the OCI referrers API.
+func (s *RegistryService) referrersAPI(ctx context.Context, namespace, repo, digest, artifactType string) ([]Descriptor, error) {
+	name := repositoryName(namespace, repo)
+	path := "/referrers/" + digest
+	if artifactType != "" {
+		path += "?artifactType=" + url.QueryEscape(artifactType)
+	}
+
+	req, err := s.newRequest(http.MethodGet, name, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MediaTypeOCIIndex)
+
+	resp, err := s.do(ctx, req, repositoryScope(name, "pull"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &Index{}
+	if err := json.Unmarshal(raw, index); err != nil {
+		return nil, err
+	}
+	return index.Manifests, nil
+}
+
+// referrersTags looks up the tags registries without the referrers API
+// use to attach artifacts to digest.
+func (s *RegistryService) referrersTags(ctx context.Context, namespace, repo, digest string) ([]Descriptor, error) {
+	prefix := strings.Replace(digest, ":", "-", 1)
+	var res []Descriptor
+
+	// The OCI referrers tag schema holds an index of the referrers.
+	m, err := s.GetManifest(ctx, namespace, repo, prefix)
+	switch {
+	case err == nil && m.IsIndex():
+		res = append(res, m.Index.Manifests...)
+	case err != nil && !isNotFound(err):
+		return nil, err
+	}
+
+	for _, c := range cosignSuffixes {
+		m, err := s.GetManifest(ctx, namespace, repo, prefix+c.suffix)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		d := Descriptor{
+			MediaType:    m.MediaType,
+			ArtifactType: c.artifactType,
+			Digest:       m.Digest,
+			Size:         int64(len(m.Raw)),
+		}
+		if m.Manifest != nil {
+			d.Annotations = m.Manifest.Annotations
+			if m.Manifest.ArtifactType != "" {
+				d.ArtifactType = m.Manifest.ArtifactType
+			}
+		}
+		res = append(res, d)
+	}
+	return res, nil
+}
@@ -0,0 +1,96 @@
+package dockerhub
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// pushArtifact stores an artifact manifest attached to subject and
+// returns its descriptor as listed by the referrers API.
+func pushArtifact(reg *fakeRegistry, name, tag, artifactType string, subject *Descriptor) Descriptor {
+	raw := mustJSONMarshal(&Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		ArtifactType:  artifactType,
+		Config:        Descriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: reg.putBlob(name, []byte("{}")), Size: 2},
+		Subject:       subject,
+		Annotations:   map[string]string{"org.opencontainers.image.created": "2023-01-02T03:04:05Z"},
+	})
+	return Descriptor{
+		MediaType:    MediaTypeOCIManifest,
+		ArtifactType: artifactType,
+		Digest:       reg.putManifest(name, tag, MediaTypeOCIManifest, raw),
+		Size:         int64(len(raw)),
+		Annotations:  map[string]string{"org.opencontainers.image.created": "2023-01-02T03:04:05Z"},
+	}
+}
+
+func TestRegistryService_Referrers(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	reg.referrers = true
+
+	image := reg.pushImage("myorg/app", "1.4.0", Platform{OS: "linux", Architecture: "amd64"}, "layer")
+	sbom := pushArtifact(reg, "myorg/app", "", "application/spdx+json", &image)
+	pushArtifact(reg, "myorg/app", "", "application/vnd.dev.sigstore.bundle.v0.3+json", &image)
+
+	res, err := client.Registry.Referrers(context.Background(), "myorg", "app", "1.4.0", "application/spdx+json")
+	if err != nil {
+		t.Fatalf("Registry.Referrers returned error: %v", err)
+	}
+
+	if want := []Descriptor{sbom}; !reflect.DeepEqual(res, want) {
+		t.Errorf("referrers are %v; want %v", res, want)
+	}
+}
+
+func TestRegistryService_Referrers_TagFallback(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	image := reg.pushImage("myorg/app", "1.4.0", Platform{OS: "linux", Architecture: "amd64"}, "layer")
+	prefix := "sha256-" + image.Digest[len("sha256:"):]
+
+	sig := pushArtifact(reg, "myorg/app", prefix+".sig", "", nil)
+	sig.ArtifactType = ArtifactTypeCosignSignature
+
+	sbom := pushArtifact(reg, "myorg/app", "", "application/spdx+json", &image)
+	reg.putManifest("myorg/app", prefix, MediaTypeOCIIndex, mustJSONMarshal(&Index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIIndex,
+		Manifests:     []Descriptor{sbom},
+	}))
+
+	res, err := client.Registry.Referrers(context.Background(), "myorg", "app", image.Digest, "")
+	if err != nil {
+		t.Fatalf("Registry.Referrers returned error: %v", err)
+	}
+
+	if want := []Descriptor{sbom, sig}; !reflect.DeepEqual(res, want) {
+		t.Errorf("referrers are %v; want %v", res, want)
+	}
+}
+
+func TestRegistryService_ResolveDigest(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	reg := newFakeRegistry(mux)
+	image := reg.pushImage("library/ubuntu", "22.04", Platform{OS: "linux", Architecture: "amd64"}, "layer")
+
+	digest, err := client.Registry.ResolveDigest(context.Background(), "library", "ubuntu", "22.04")
+	if err != nil {
+		t.Fatalf("Registry.ResolveDigest returned error: %v", err)
+	}
+	if digest != image.Digest {
+		t.Errorf("digest is %s; want %s", digest, image.Digest)
+	}
+
+	if _, err := client.Registry.ResolveDigest(context.Background(), "library", "ubuntu", "missing"); !isNotFound(err) {
+		t.Errorf("Registry.ResolveDigest error is %v; want 404", err)
+	}
+}
@@ -0,0 +1,246 @@
+package dockerhub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RegistryService handles communication with the registry API, which
+// serves the manifests and blobs making up images. Requests are
+// authorized with bearer tokens obtained from the registry's token
+// server for each repository scope.
+type RegistryService service
+
+// registryToken is a bearer token cached for a set of scopes.
+type registryToken struct {
+	value   string
+	expires time.Time
+}
+
+// tokenResponse is the payload returned by a registry token server.
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// repositoryName returns the registry name of a repository. Official
+// images live in the "library" namespace.
+func repositoryName(namespace, repo string) string {
+	if namespace == "" {
+		namespace = "library"
+	}
+	return namespace + "/" + repo
+}
+
+// repositoryScope returns the token scope granting actions on the named
+// repository.
+func repositoryScope(name string, actions ...string) string {
+	return fmt.Sprintf("repository:%s:%s", name, strings.Join(actions, ","))
+}
+
+// GetToken obtains a bearer token for the given scopes, such as
+// "repository:library/ubuntu:pull", from the client's RegistryAuthURL.
+// Tokens are cached until shortly before they expire.
+func (s *RegistryService) GetToken(ctx context.Context, scopes ...string) (string, error) {
+	ctx = withOperation(ctx, "Registry.GetToken", "", "")
+	return s.token(ctx, s.client.RegistryAuthURL.String(), defaultRegistryService, scopes, false)
+}
+
+// token returns a cached token for the scopes from realm, fetching a new
+// one when there is none or refresh is set.
+func (s *RegistryService) token(ctx context.Context, realm, service string, scopes []string, refresh bool) (string, error) {
+	sorted := append([]string(nil), scopes...)
+	sort.Strings(sorted)
+	key := realm + "|" + service + "|" + strings.Join(sorted, " ")
+
+	c := s.client
+	c.registryMu.Lock()
+	tok, ok := c.registryTokens[key]
+	username, password := c.registryUsername, c.registryPassword
+	c.registryMu.Unlock()
+
+	if ok && !refresh && time.Now().Before(tok.expires) {
+		return tok.value, nil
+	}
+
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if service != "" {
+		q.Set("service", service)
+	}
+	for _, scope := range sorted {
+		q.Add("scope", scope)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	// Registry credentials are only sent to the configured token server,
+	// so that a challenge cannot redirect them to another host.
+	if username != "" && u.Scheme == c.RegistryAuthURL.Scheme && u.Host == c.RegistryAuthURL.Host {
+		req.SetBasicAuth(username, password)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	res := &tokenResponse{}
+	if _, err := c.Do(withoutCache(ctx), req, res); err != nil {
+		return "", err
+	}
+
+	value := res.Token
+	if value == "" {
+		value = res.AccessToken
+	}
+	if value == "" {
+		return "", errors.New("did not receive registry token")
+	}
+
+	// Tokens without an expiry are valid for 60 seconds.
+	expiresIn := res.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = 60
+	}
+	tok = registryToken{
+		value:   value,
+		expires: time.Now().Add(time.Duration(expiresIn)*time.Second - 10*time.Second),
+	}
+
+	c.registryMu.Lock()
+	c.registryTokens[key] = tok
+	c.registryMu.Unlock()
+
+	return tok.value, nil
+}
+
+// newRequest creates a registry API request for the named repository.
+// The given path is relative to the repository, e.g. "/manifests/latest".
+func (s *RegistryService) newRequest(method, name, path string, body []byte) (*http.Request, error) {
+	u, err := s.client.RegistryURL.Parse("/v2/" + name + path)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf io.Reader
+	if body != nil {
+		buf = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, u.String(), buf)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", s.client.UserAgent)
+	return req, nil
+}
+
+// do sends a registry API request authorized for the given scopes. If
+// the registry challenges the token, a new one is requested from the
+// realm it names and the request is retried once. The caller must close
+// the response body.
+func (s *RegistryService) do(ctx context.Context, req *http.Request, scopes ...string) (*http.Response, error) {
+	ctx = withoutCache(ctx)
+	tok, err := s.GetToken(ctx, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+
+	resp, err := s.client.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Streamed bodies cannot be sent again, so they are not retried.
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+		params, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+		if ok && params["realm"] != "" && replayable {
+			resp.Body.Close()
+
+			if scope := params["scope"]; scope != "" && !containsString(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+			tok, err := s.token(ctx, params["realm"], params["service"], scopes, true)
+			if err != nil {
+				return nil, err
+			}
+
+			retry := req.Clone(ctx)
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			retry.Header.Set("Authorization", "Bearer "+tok)
+
+			if resp, err = s.client.send(withAttempt(ctx, 2), retry); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseBearerChallenge parses the parameters of a WWW-Authenticate header
+// using the Bearer scheme, such as
+//
+//	Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/ubuntu:pull"
+func parseBearerChallenge(header string) (map[string]string, bool) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "bearer") {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				return nil, false
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+	return params, true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
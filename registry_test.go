@@ -0,0 +1,361 @@
+package dockerhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseBearerChallenge(t *testing.T) {
+	params, ok := parseBearerChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:samalba/my-app:pull,push"`)
+	if !ok {
+		t.Fatalf("parseBearerChallenge did not parse challenge")
+	}
+
+	want := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:samalba/my-app:pull,push",
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params are %v; want %v", params, want)
+	}
+
+	if _, ok := parseBearerChallenge(`Basic realm="registry"`); ok {
+		t.Errorf("parseBearerChallenge parsed a Basic challenge")
+	}
+}
+
+func TestRegistryService_GetToken(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		q := r.URL.Query()
+		if got := q.Get("service"); got != defaultRegistryService {
+			t.Errorf("service is %s; want %s", got, defaultRegistryService)
+		}
+		if got, want := q["scope"], []string{"repository:library/ubuntu:pull"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("scope is %v; want %v", got, want)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "username" || pass != "password" {
+			t.Errorf("basic auth is %s:%s; want username:password", user, pass)
+		}
+		w.Write(mustJSONMarshal(map[string]interface{}{"token": "bogus", "expires_in": 300}))
+	}))
+	defer srv.Close()
+
+	client := NewClient(nil)
+	client.RegistryAuthURL, _ = url.Parse(srv.URL)
+	client.SetRegistryAuth("username", "password")
+
+	for i := 0; i < 2; i++ {
+		tok, err := client.Registry.GetToken(context.Background(), "repository:library/ubuntu:pull")
+		if err != nil {
+			t.Fatalf("Registry.GetToken returned error: %v", err)
+		}
+		if tok != "bogus" {
+			t.Errorf("token is %s; want bogus", tok)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("token server called %d times; want 1", calls)
+	}
+}
+
+func TestRegistryService_Challenge(t *testing.T) {
+	client, mux, teardown := makeMockRegistry()
+	defer teardown()
+
+	// Point the client at a token server whose tokens the registry
+	// rejects, so that it must follow the challenge.
+	stale := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mustJSONMarshal(map[string]interface{}{"token": "stale"}))
+	}))
+	defer stale.Close()
+	client.RegistryAuthURL, _ = url.Parse(stale.URL)
+
+	digest := digestOf([]byte("blob"))
+	mux.HandleFunc("/v2/library/ubuntu/blobs/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("blob"))
+	})
+
+	b, err := client.Registry.GetBlob(context.Background(), "", "ubuntu", digest)
+	if err != nil {
+		t.Fatalf("Registry.GetBlob returned error: %v", err)
+	}
+	if got := string(b); got != "blob" {
+		t.Errorf("blob is %s; want blob", got)
+	}
+}
+
+func TestRegistryService_Challenge_ForeignRealm(t *testing.T) {
+	var configuredAuth, foreignAuth string
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		configuredAuth = r.Header.Get("Authorization")
+		w.Write(mustJSONMarshal(map[string]interface{}{"token": "stale"}))
+	}))
+	defer auth.Close()
+
+	digest := digestOf([]byte("blob"))
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			foreignAuth = r.Header.Get("Authorization")
+			w.Write(mustJSONMarshal(map[string]interface{}{"token": "fresh"}))
+		case r.Header.Get("Authorization") == "Bearer fresh":
+			w.Write([]byte("blob"))
+		default:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(nil)
+	client.RegistryURL, _ = url.Parse(srv.URL)
+	client.RegistryAuthURL, _ = url.Parse(auth.URL)
+	client.SetRegistryAuth("someone", "password")
+
+	if _, err := client.Registry.GetBlob(context.Background(), "", "ubuntu", digest); err != nil {
+		t.Fatalf("Registry.GetBlob returned error: %v", err)
+	}
+	if !strings.HasPrefix(configuredAuth, "Basic ") {
+		t.Errorf("configured token server got Authorization %q; want basic auth", configuredAuth)
+	}
+	if foreignAuth != "" {
+		t.Errorf("challenged token server got Authorization %q; want none", foreignAuth)
+	}
+}
+
+func TestRegistryService_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write(mustJSONMarshal(map[string]interface{}{"token": "bogus"}))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := NewClient(nil)
+	client.RegistryURL, _ = url.Parse(srv.URL)
+	client.RegistryAuthURL, _ = url.Parse(srv.URL + "/token")
+
+	_, err := client.Registry.GetManifest(context.Background(), "library", "ubuntu", "latest")
+	if want := fmt.Sprintf("request failed with status %d", http.StatusUnauthorized); err == nil || err.Error() != want {
+		t.Errorf("Registry.GetManifest error is %v; want %s", err, want)
+	}
+}
+
+// fakeManifest is a manifest stored in a fakeRegistry.
+type fakeManifest struct {
+	mediaType string
+	raw       []byte
+}
+
+// fakeRegistry is an in-memory registry which serves manifests and blobs
+// and accepts pushes, mounts and monolithic uploads.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	manifests map[string]map[string]fakeManifest
+	blobs     map[string]map[string][]byte
+	uploads   int
+
+	// noMount makes the registry refuse cross-repository mounts.
+	noMount bool
+
+	// referrers enables the OCI referrers API.
+	referrers bool
+}
+
+// newFakeRegistry serves a fakeRegistry under /v2/ on mux.
+func newFakeRegistry(mux *http.ServeMux) *fakeRegistry {
+	f := &fakeRegistry{
+		manifests: make(map[string]map[string]fakeManifest),
+		blobs:     make(map[string]map[string][]byte),
+	}
+	mux.Handle("/v2/", f)
+	return f
+}
+
+func (f *fakeRegistry) putBlob(name string, b []byte) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.blobs[name] == nil {
+		f.blobs[name] = make(map[string][]byte)
+	}
+	digest := digestOf(b)
+	f.blobs[name][digest] = b
+	return digest
+}
+
+func (f *fakeRegistry) blob(name, digest string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.blobs[name][digest]
+	return b, ok
+}
+
+func (f *fakeRegistry) putManifest(name, ref, mediaType string, raw []byte) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.manifests[name] == nil {
+		f.manifests[name] = make(map[string]fakeManifest)
+	}
+	digest := digestOf(raw)
+	f.manifests[name][digest] = fakeManifest{mediaType, raw}
+	if ref != "" {
+		f.manifests[name][ref] = fakeManifest{mediaType, raw}
+	}
+	return digest
+}
+
+func (f *fakeRegistry) manifest(name, ref string) (fakeManifest, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, ok := f.manifests[name][ref]
+	return m, ok
+}
+
+// pushImage stores a single-layer image for platform under tag and
+// returns the descriptor of its manifest.
+func (f *fakeRegistry) pushImage(name, tag string, platform Platform, layer string) Descriptor {
+	config := mustJSONMarshal(&ImageConfig{Architecture: platform.Architecture, OS: platform.OS, Variant: platform.Variant})
+	raw := mustJSONMarshal(&Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		Config:        Descriptor{MediaType: MediaTypeOCIConfig, Digest: f.putBlob(name, config), Size: int64(len(config))},
+		Layers:        []Descriptor{{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: f.putBlob(name, []byte(layer)), Size: int64(len(layer))}},
+	})
+	return Descriptor{
+		MediaType: MediaTypeOCIManifest,
+		Digest:    f.putManifest(name, tag, MediaTypeOCIManifest, raw),
+		Size:      int64(len(raw)),
+		Platform:  &platform,
+	}
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+	switch {
+	case strings.Contains(path, "/referrers/") && f.referrers:
+		i := strings.Index(path, "/referrers/")
+		f.serveReferrers(w, r, path[:i], path[i+len("/referrers/"):])
+	case strings.Contains(path, "/manifests/"):
+		i := strings.Index(path, "/manifests/")
+		f.serveManifest(w, r, path[:i], path[i+len("/manifests/"):])
+	case strings.Contains(path, "/blobs/uploads/"):
+		i := strings.Index(path, "/blobs/uploads/")
+		f.serveUpload(w, r, path[:i], path[i+len("/blobs/uploads/"):])
+	case strings.Contains(path, "/blobs/"):
+		i := strings.Index(path, "/blobs/")
+		b, ok := f.blob(path[:i], path[i+len("/blobs/"):])
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(b)))
+		if r.Method != http.MethodHead {
+			w.Write(b)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeRegistry) serveManifest(w http.ResponseWriter, r *http.Request, name, ref string) {
+	if r.Method == http.MethodPut {
+		raw, _ := io.ReadAll(r.Body)
+		w.Header().Set("Docker-Content-Digest", f.putManifest(name, ref, r.Header.Get("Content-Type"), raw))
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	m, ok := f.manifest(name, ref)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", m.mediaType)
+	w.Header().Set("Docker-Content-Digest", digestOf(m.raw))
+	if r.Method != http.MethodHead {
+		w.Write(m.raw)
+	}
+}
+
+func (f *fakeRegistry) serveUpload(w http.ResponseWriter, r *http.Request, name, session string) {
+	switch r.Method {
+	case http.MethodPost:
+		q := r.URL.Query()
+		if b, ok := f.blob(q.Get("from"), q.Get("mount")); ok && !f.noMount {
+			f.putBlob(name, b)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/session", name))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		b, _ := io.ReadAll(r.Body)
+		if digestOf(b) != r.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.putBlob(name, b)
+		f.mu.Lock()
+		f.uploads++
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeRegistry) serveReferrers(w http.ResponseWriter, r *http.Request, name, digest string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	artifactType := r.URL.Query().Get("artifactType")
+	index := &Index{SchemaVersion: 2, MediaType: MediaTypeOCIIndex, Manifests: []Descriptor{}}
+	for ref, m := range f.manifests[name] {
+		if ref != digestOf(m.raw) {
+			continue
+		}
+		manifest := &Manifest{}
+		if err := json.Unmarshal(m.raw, manifest); err != nil || manifest.Subject == nil || manifest.Subject.Digest != digest {
+			continue
+		}
+
+		d := Descriptor{
+			MediaType:    m.mediaType,
+			ArtifactType: manifest.ArtifactType,
+			Digest:       ref,
+			Size:         int64(len(m.raw)),
+			Annotations:  manifest.Annotations,
+		}
+		if d.ArtifactType == "" {
+			d.ArtifactType = manifest.Config.MediaType
+		}
+		if artifactType == "" || d.ArtifactType == artifactType {
+			index.Manifests = append(index.Manifests, d)
+		}
+	}
+
+	if artifactType != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	w.Header().Set("Content-Type", MediaTypeOCIIndex)
+	w.Write(mustJSONMarshal(index))
+}
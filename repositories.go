@@ -79,6 +79,7 @@ func (s RepositoriesService) buildRepoSlug(namespace, repo string) string {
 
 // CreateRepository create a repository.
 func (s *RepositoriesService) CreateRepository(ctx context.Context, namespace, name, description string, isPrivate bool) (*Repository, error) {
+	ctx = withOperation(ctx, "Repositories.CreateRepository", namespace, name)
 	url := "/repositories/"
 	repo := &CreateRepositoryRequest{
 		Namespace:   namespace,
@@ -110,6 +111,7 @@ func (s *RepositoriesService) CreateRepository(ctx context.Context, namespace, n
 
 // EditRepository updates a repository.
 func (s *RepositoriesService) EditRepository(ctx context.Context, namespace, repo string, patch *RepositoryPatch) (*Repository, error) {
+	ctx = withOperation(ctx, "Repositories.EditRepository", namespace, repo)
 	slug := s.buildRepoSlug(namespace, repo)
 	req, err := s.client.NewRequest(http.MethodPatch, slug, patch)
 	if err != nil {
@@ -126,6 +128,7 @@ func (s *RepositoriesService) EditRepository(ctx context.Context, namespace, rep
 
 // GetRepository gets details for a given repository.
 func (s *RepositoriesService) GetRepository(ctx context.Context, namespace, repo string) (*Repository, error) {
+	ctx = withOperation(ctx, "Repositories.GetRepository", namespace, repo)
 	slug := s.buildRepoSlug(namespace, repo)
 	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
 	if err != nil {
@@ -142,6 +145,7 @@ func (s *RepositoriesService) GetRepository(ctx context.Context, namespace, repo
 
 // SetRepositoryPrivacy sets the privacy status of a repository.
 func (s *RepositoriesService) SetRepositoryPrivacy(ctx context.Context, namespace, repo string, isPrivate bool) error {
+	ctx = withOperation(ctx, "Repositories.SetRepositoryPrivacy", namespace, repo)
 	slug := s.buildRepoSlug(namespace, repo) + "privacy/"
 	req, err := s.client.NewRequest(http.MethodPost, slug, &RepositoryPrivacyPatch{
 		IsPrivate: isPrivate,
@@ -158,6 +162,7 @@ func (s *RepositoriesService) SetRepositoryPrivacy(ctx context.Context, namespac
 
 // GetRepositories gets all repositories from a given Dockerhub namespace.
 func (s *RepositoriesService) GetRepositories(ctx context.Context, namespace string) (*RepositoryList, error) {
+	ctx = withOperation(ctx, "Repositories.GetRepositories", namespace, "")
 	slug := fmt.Sprintf("/repositories/%s/", namespace)
 	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
 	if err != nil {
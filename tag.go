@@ -15,38 +15,46 @@ type Tags struct {
 	Count    int         `json:"count"`
 	Next     interface{} `json:"next"`
 	Previous interface{} `json:"previous"`
-	Results  []struct {
-		Creator int         `json:"creator"`
-		ID      int         `json:"id"`
-		ImageID interface{} `json:"image_id"`
-		Images  []struct {
-			Architecture string      `json:"architecture"`
-			Features     string      `json:"features"`
-			Variant      interface{} `json:"variant"`
-			Digest       string      `json:"digest"`
-			Os           string      `json:"os"`
-			OsFeatures   string      `json:"os_features"`
-			OsVersion    interface{} `json:"os_version"`
-			Size         int         `json:"size"`
-			Status       string      `json:"status"`
-			LastPulled   time.Time   `json:"last_pulled"`
-			LastPushed   time.Time   `json:"last_pushed"`
-		} `json:"images"`
-		LastUpdated         time.Time `json:"last_updated"`
-		LastUpdater         int       `json:"last_updater"`
-		LastUpdaterUsername string    `json:"last_updater_username"`
-		Name                string    `json:"name"`
-		Repository          int       `json:"repository"`
-		FullSize            int       `json:"full_size"`
-		V2                  bool      `json:"v2"`
-		TagStatus           string    `json:"tag_status"`
-		TagLastPulled       time.Time `json:"tag_last_pulled"`
-		TagLastPushed       time.Time `json:"tag_last_pushed"`
-	} `json:"results"`
+	Results  []Tag       `json:"results"`
+}
+
+// Tag of a repository
+type Tag struct {
+	Creator             int         `json:"creator"`
+	ID                  int         `json:"id"`
+	ImageID             interface{} `json:"image_id"`
+	Images              []TagImage  `json:"images"`
+	LastUpdated         time.Time   `json:"last_updated"`
+	LastUpdater         int         `json:"last_updater"`
+	LastUpdaterUsername string      `json:"last_updater_username"`
+	Name                string      `json:"name"`
+	Repository          int         `json:"repository"`
+	FullSize            int         `json:"full_size"`
+	V2                  bool        `json:"v2"`
+	TagStatus           string      `json:"tag_status"`
+	TagLastPulled       time.Time   `json:"tag_last_pulled"`
+	TagLastPushed       time.Time   `json:"tag_last_pushed"`
+	Digest              string      `json:"digest,omitempty"`
+}
+
+// TagImage is the image of a tag for one platform
+type TagImage struct {
+	Architecture string      `json:"architecture"`
+	Features     string      `json:"features"`
+	Variant      interface{} `json:"variant"`
+	Digest       string      `json:"digest"`
+	Os           string      `json:"os"`
+	OsFeatures   string      `json:"os_features"`
+	OsVersion    interface{} `json:"os_version"`
+	Size         int         `json:"size"`
+	Status       string      `json:"status"`
+	LastPulled   time.Time   `json:"last_pulled"`
+	LastPushed   time.Time   `json:"last_pushed"`
 }
 
 // GetTags of the repo
 func (s *TagService) GetTags(ctx context.Context, namespace, repo string, page int) (*Tags, error) {
+	ctx = withOperation(ctx, "Tag.GetTags", namespace, repo)
 	slug := fmt.Sprintf("/repositories/%v/%v/tags/?page_size=%d&ordering=last_updated", namespace, repo, page)
 
 	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
@@ -60,3 +68,20 @@ func (s *TagService) GetTags(ctx context.Context, namespace, repo string, page i
 	}
 	return res, nil
 }
+
+// GetTag of the repo by name
+func (s *TagService) GetTag(ctx context.Context, namespace, repo, tag string) (*Tag, error) {
+	ctx = withOperation(ctx, "Tag.GetTag", namespace, repo)
+	slug := fmt.Sprintf("/repositories/%v/%v/tags/%v/", namespace, repo, tag)
+
+	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Tag{}
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
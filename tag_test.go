@@ -0,0 +1,62 @@
+package dockerhub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTagService_GetTags(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	namespace := "library"
+	repo := "ubuntu"
+	tags := &Tags{Count: 1, Results: []Tag{{Name: "latest", Images: []TagImage{{Architecture: "amd64", Os: "linux"}}}}}
+
+	uri := fmt.Sprintf("/repositories/%s/%s/tags/", namespace, repo)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		if got := r.URL.Query().Get("page_size"); got != "10" {
+			t.Errorf("page_size is %s; want 10", got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(mustJSONMarshal(tags))
+	})
+
+	res, err := client.Tag.GetTags(context.Background(), namespace, repo, 10)
+	if err != nil {
+		t.Errorf("Tag.GetTags returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, tags) {
+		t.Errorf("tags are %v; want %v", res, tags)
+	}
+}
+
+func TestTagService_GetTag(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	namespace := "library"
+	repo := "ubuntu"
+	tag := &Tag{Name: "22.04", Digest: "sha256:abc"}
+
+	uri := fmt.Sprintf("/repositories/%s/%s/tags/%s/", namespace, repo, tag.Name)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		w.WriteHeader(http.StatusOK)
+		w.Write(mustJSONMarshal(tag))
+	})
+
+	res, err := client.Tag.GetTag(context.Background(), namespace, repo, tag.Name)
+	if err != nil {
+		t.Errorf("Tag.GetTag returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, tag) {
+		t.Errorf("tag is %v; want %v", res, tag)
+	}
+}
@@ -27,6 +27,7 @@ type User struct {
 
 // GetLoggedInUser get the current user logged in to docker hub
 func (s *UserService) GetLoggedInUser(ctx context.Context) (*User, error) {
+	ctx = withOperation(ctx, "User.GetLoggedInUser", "", "")
 	url := "/user/"
 
 	req, err := s.client.NewRequest(http.MethodGet, url, nil)
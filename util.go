@@ -12,3 +12,8 @@ func StringValue(s *string) string {
 	}
 	return ""
 }
+
+// Bool returns a pointer to a bool for configuration.
+func Bool(b bool) *bool {
+	return &b
+}
@@ -2,6 +2,7 @@ package dockerhub
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -70,6 +71,7 @@ func (s WebhookService) buildWebhookSlug(namespace, repo string) string {
 
 // CreateWebhook create webhook for the triggers
 func (s *WebhookService) CreateWebhook(ctx context.Context, namespace, repo, name, url string) (*WebhookResponse, error) {
+	ctx = withOperation(ctx, "Webhook.CreateWebhook", namespace, repo)
 	slug := s.buildWebhookSlug(namespace, repo)
 
 	hook := &WebhookRequest{
@@ -98,6 +100,7 @@ func (s *WebhookService) CreateWebhook(ctx context.Context, namespace, repo, nam
 
 // GetWebhooks Get the related webhooks
 func (s *WebhookService) GetWebhooks(ctx context.Context, namespace, repo string) (*WebhookResponse, error) {
+	ctx = withOperation(ctx, "Webhook.GetWebhooks", namespace, repo)
 	slug := s.buildWebhookSlug(namespace, repo)
 
 	req, err := s.client.NewRequest(http.MethodGet, slug, nil)
@@ -114,6 +117,7 @@ func (s *WebhookService) GetWebhooks(ctx context.Context, namespace, repo string
 
 // DeleteWebhook Delete the existing webhooks
 func (s *WebhookService) DeleteWebhook(ctx context.Context, namespace, repo, name string) error {
+	ctx = withOperation(ctx, "Webhook.DeleteWebhook", namespace, repo)
 	slug := s.buildWebhookSlug(namespace, repo)
 
 	webhookURL := fmt.Sprintf("%s%s/", slug, name)
@@ -127,3 +131,46 @@ func (s *WebhookService) DeleteWebhook(ctx context.Context, namespace, repo, nam
 	}
 	return nil
 }
+
+// GetWebhook Get a single webhook pipeline by its slug
+func (s *WebhookService) GetWebhook(ctx context.Context, namespace, repo, slug string) (*Results, error) {
+	ctx = withOperation(ctx, "Webhook.GetWebhook", namespace, repo)
+	webhookURL := fmt.Sprintf("%s%s/", s.buildWebhookSlug(namespace, repo), slug)
+
+	req, err := s.client.NewRequest(http.MethodGet, webhookURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := &Results{}
+
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateWebhook Update the name, hook URLs or callback setting of an
+// existing webhook pipeline in place, keeping its history
+func (s *WebhookService) UpdateWebhook(ctx context.Context, namespace, repo, slug string, hook *WebhookRequest) (*Results, error) {
+	ctx = withOperation(ctx, "Webhook.UpdateWebhook", namespace, repo)
+	webhookURL := fmt.Sprintf("%s%s/", s.buildWebhookSlug(namespace, repo), slug)
+
+	if hook == nil {
+		return nil, errors.New("webhook request is required")
+	}
+	payload := *hook
+	if payload.Registry == "" {
+		payload.Registry = "registry-1.docker.io"
+	}
+
+	req, err := s.client.NewRequest(http.MethodPatch, webhookURL, &payload)
+	if err != nil {
+		return nil, err
+	}
+	res := &Results{}
+
+	if _, err := s.client.Do(ctx, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
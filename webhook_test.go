@@ -75,3 +75,91 @@ func TestWebhookService_GetWebhooks(t *testing.T) {
 		t.Errorf("webhook is %v; want %v", res, hook)
 	}
 }
+
+func TestWebhookService_GetWebhook(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	namespace := "namespace"
+	repo := "repo"
+	slug := "deploy"
+
+	hook := &Results{
+		Name: "deploy",
+		Slug: slug,
+		Webhooks: []Webhooks{
+			{Name: "deploy", HookURL: "https://example.com/a"},
+		},
+	}
+
+	uri := fmt.Sprintf("/repositories/%s/%s/webhook_pipeline/%s/", namespace, repo, slug)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		w.WriteHeader(http.StatusOK)
+		w.Write(mustJSONMarshal(hook))
+	})
+
+	res, err := client.Webhook.GetWebhook(context.Background(), namespace, repo, slug)
+	if err != nil {
+		t.Errorf("Webhook.GetWebhook returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, hook) {
+		t.Errorf("webhook is %v; want %v", res, hook)
+	}
+}
+
+func TestWebhookService_UpdateWebhook(t *testing.T) {
+	client, mux, teardown := makeMockClient()
+	defer teardown()
+
+	namespace := "namespace"
+	repo := "repo"
+	slug := "deploy"
+
+	update := &WebhookRequest{
+		Name:                "deploy",
+		ExpectFinalCallback: true,
+		Webhooks: []Webhooks{
+			{Name: "primary", HookURL: "https://example.com/a"},
+			{Name: "secondary", HookURL: "https://example.com/b"},
+		},
+	}
+	hook := &Results{
+		Name:                update.Name,
+		Slug:                slug,
+		ExpectFinalCallback: true,
+		Webhooks:            update.Webhooks,
+	}
+
+	uri := fmt.Sprintf("/repositories/%s/%s/webhook_pipeline/%s/", namespace, repo, slug)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPatch)
+		assertBody(t, r, string(mustJSONMarshal(&WebhookRequest{
+			Name:                update.Name,
+			ExpectFinalCallback: true,
+			Webhooks:            update.Webhooks,
+			Registry:            "registry-1.docker.io",
+		})))
+		w.WriteHeader(http.StatusOK)
+		w.Write(mustJSONMarshal(hook))
+	})
+
+	res, err := client.Webhook.UpdateWebhook(context.Background(), namespace, repo, slug, update)
+	if err != nil {
+		t.Errorf("Webhook.UpdateWebhook returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res, hook) {
+		t.Errorf("webhook is %v; want %v", res, hook)
+	}
+}
+
+func TestWebhookService_UpdateWebhook_Nil(t *testing.T) {
+	client, _, teardown := makeMockClient()
+	defer teardown()
+
+	if _, err := client.Webhook.UpdateWebhook(context.Background(), "namespace", "repo", "slug", nil); err == nil {
+		t.Error("Webhook.UpdateWebhook with a nil hook returned no error")
+	}
+}
@@ -0,0 +1,80 @@
+package webhookreceiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// States which may be reported to a callback_url.
+const (
+	StateSuccess = "success"
+	StateFailure = "failure"
+	StateError   = "error"
+)
+
+// CallbackResponse is the result posted to the callback_url of a push
+// event, which completes a pipeline created with ExpectFinalCallback.
+type CallbackResponse struct {
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	Context     string `json:"context,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+// SendCallback posts resp to callbackURL. If httpClient is nil,
+// http.DefaultClient is used. Unlike Handler, it does not check where
+// callbackURL points, which is up to the caller.
+func SendCallback(ctx context.Context, httpClient *http.Client, callbackURL string, resp *CallbackResponse) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("callback failed with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// result reduces the outcome of the registered callbacks to the response
+// reported to callback_url: the first error, else the first non-success
+// result, else the last result given, else success.
+func result(results []*CallbackResponse, err error) *CallbackResponse {
+	if err != nil {
+		return &CallbackResponse{State: StateError, Description: err.Error()}
+	}
+
+	var last *CallbackResponse
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if r.State != StateSuccess {
+			return r
+		}
+		last = r
+	}
+	if last != nil {
+		return last
+	}
+	return &CallbackResponse{State: StateSuccess}
+}
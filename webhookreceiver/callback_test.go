@@ -0,0 +1,160 @@
+package webhookreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// callbackServer records the CallbackResponse posted to it.
+func callbackServer(t *testing.T) (srv *httptest.Server, got *CallbackResponse) {
+	got = &CallbackResponse{}
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("callback method is %s; want POST", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("decoding callback: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return srv, got
+}
+
+func TestSendCallback(t *testing.T) {
+	srv, got := callbackServer(t)
+	defer srv.Close()
+
+	want := &CallbackResponse{
+		State:       StateSuccess,
+		Description: "deployed",
+		Context:     "ci",
+		TargetURL:   "https://ci.example.com/1",
+	}
+	if err := SendCallback(context.Background(), srv.Client(), srv.URL, want); err != nil {
+		t.Fatalf("SendCallback returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("callback is %v; want %v", got, want)
+	}
+}
+
+func TestSendCallback_Status(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if err := SendCallback(context.Background(), srv.Client(), srv.URL, &CallbackResponse{State: StateSuccess}); err == nil {
+		t.Errorf("SendCallback succeeded on 404; want error")
+	}
+}
+
+func TestHandler_SendCallbacks(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		fn     ResultFunc
+		want   *CallbackResponse
+		status int
+	}{
+		{
+			"success",
+			func(context.Context, *PushEvent) (*CallbackResponse, error) { return nil, nil },
+			&CallbackResponse{State: StateSuccess},
+			http.StatusOK,
+		},
+		{
+			"failure",
+			func(context.Context, *PushEvent) (*CallbackResponse, error) {
+				return &CallbackResponse{State: StateFailure, Description: "tests failed"}, nil
+			},
+			&CallbackResponse{State: StateFailure, Description: "tests failed"},
+			http.StatusOK,
+		},
+		{
+			"error",
+			func(context.Context, *PushEvent) (*CallbackResponse, error) { return nil, errors.New("boom") },
+			&CallbackResponse{State: StateError, Description: "boom"},
+			http.StatusInternalServerError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cb, got := callbackServer(t)
+			defer cb.Close()
+
+			h := NewHandler()
+			h.SendCallbacks = true
+			h.HTTPClient = cb.Client()
+			h.AllowedCallbackHosts = []string{cb.Listener.Addr().String()}
+			h.OnPushResult(tc.fn)
+
+			body := strings.Replace(pushPayload,
+				"https://registry.hub.docker.com/u/svendowideit/testhook/hook/2141b5bi5i5b02bec211i4eeih0242eg11000a/",
+				cb.URL, 1)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+			if rec.Code != tc.status {
+				t.Errorf("status is %d; want %d", rec.Code, tc.status)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("callback is %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandler_SendCallbacksForeignHost(t *testing.T) {
+	requests := 0
+	foreign := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer foreign.Close()
+
+	for _, tc := range []struct {
+		name        string
+		callbackURL string
+		allowed     []string
+	}{
+		{"default hosts", foreign.URL, nil},
+		{"other host", foreign.URL, []string{"registry.hub.docker.com"}},
+		{"plain http", strings.Replace(foreign.URL, "https:", "http:", 1), []string{foreign.Listener.Addr().String()}},
+		{"lookalike host", "https://registry.hub.docker.com.example.com/hook/", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewHandler()
+			h.SendCallbacks = true
+			h.HTTPClient = foreign.Client()
+			h.AllowedCallbackHosts = tc.allowed
+			h.OnPush(func(context.Context, *PushEvent) error {
+				called = true
+				return nil
+			})
+
+			body := strings.Replace(pushPayload,
+				"https://registry.hub.docker.com/u/svendowideit/testhook/hook/2141b5bi5i5b02bec211i4eeih0242eg11000a/",
+				tc.callbackURL, 1)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status is %d; want %d", rec.Code, http.StatusBadRequest)
+			}
+			if called {
+				t.Errorf("handler was called for an event with a foreign callback_url")
+			}
+		})
+	}
+	if requests != 0 {
+		t.Errorf("foreign host got %d callbacks; want 0", requests)
+	}
+}
@@ -0,0 +1,108 @@
+// Package webhookreceiver parses and dispatches the webhook payloads that
+// Dockerhub sends when an image is pushed to a repository.
+package webhookreceiver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// UnixTime is a timestamp sent as seconds since the epoch. Dockerhub
+// sends both integer and fractional values.
+type UnixTime struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes a numeric epoch timestamp.
+func (t *UnixTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return err
+	}
+	sec := int64(f)
+	t.Time = time.Unix(sec, int64((f-float64(sec))*1e9)).UTC()
+	return nil
+}
+
+// MarshalJSON encodes the timestamp as whole seconds since the epoch.
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("0"), nil
+	}
+	return []byte(strconv.FormatInt(t.Unix(), 10)), nil
+}
+
+// PushData describes the push which triggered the webhook.
+type PushData struct {
+	PushedAt UnixTime `json:"pushed_at"`
+	Pusher   string   `json:"pusher"`
+	Tag      string   `json:"tag"`
+	Images   []string `json:"images,omitempty"`
+}
+
+// Repository describes the repository which was pushed to.
+type Repository struct {
+	CommentCount    int      `json:"comment_count"`
+	DateCreated     UnixTime `json:"date_created"`
+	Description     string   `json:"description"`
+	Dockerfile      string   `json:"dockerfile"`
+	FullDescription string   `json:"full_description"`
+	IsOfficial      bool     `json:"is_official"`
+	IsPrivate       bool     `json:"is_private"`
+	IsTrusted       bool     `json:"is_trusted"`
+	Name            string   `json:"name"`
+	Namespace       string   `json:"namespace"`
+	Owner           string   `json:"owner"`
+	RepoName        string   `json:"repo_name"`
+	RepoURL         string   `json:"repo_url"`
+	StarCount       int      `json:"star_count"`
+	Status          string   `json:"status"`
+}
+
+// PushEvent is the payload Dockerhub posts to a webhook when an image is
+// pushed. CallbackURL is used to report the outcome of pipelines created
+// with ExpectFinalCallback.
+type PushEvent struct {
+	CallbackURL string     `json:"callback_url"`
+	PushData    PushData   `json:"push_data"`
+	Repository  Repository `json:"repository"`
+}
+
+// ParsePushEvent decodes and validates a PushEvent.
+func ParsePushEvent(r io.Reader) (*PushEvent, error) {
+	ev := &PushEvent{}
+	if err := json.NewDecoder(r).Decode(ev); err != nil {
+		return nil, err
+	}
+	if err := ev.Validate(); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// Validate checks that the fields needed to act on the event are present.
+func (ev *PushEvent) Validate() error {
+	if ev.Repository.RepoName == "" {
+		return errors.New("missing repository.repo_name")
+	}
+	if ev.PushData.Tag == "" {
+		return errors.New("missing push_data.tag")
+	}
+	if ev.CallbackURL != "" {
+		u, err := url.Parse(ev.CallbackURL)
+		if err != nil {
+			return errors.New("invalid callback_url")
+		}
+		if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("invalid callback_url")
+		}
+	}
+	return nil
+}
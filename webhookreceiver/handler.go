@@ -0,0 +1,152 @@
+package webhookreceiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// defaultMaxBodySize bounds the payload read from a request. Push events
+// embed the full repository description, which Dockerhub caps at 25000
+// bytes.
+const defaultMaxBodySize = 1 << 20
+
+// defaultCallbackHost is the host Dockerhub's callback_url points to.
+const defaultCallbackHost = "registry.hub.docker.com"
+
+// HandlerFunc is called for every valid push event received.
+type HandlerFunc func(ctx context.Context, ev *PushEvent) error
+
+// ResultFunc is called for every valid push event received and returns
+// the result to report to the event's callback_url. A nil result counts
+// as success.
+type ResultFunc func(ctx context.Context, ev *PushEvent) (*CallbackResponse, error)
+
+// A Handler is an http.Handler which receives Dockerhub push webhooks and
+// dispatches them to the registered callbacks in registration order.
+type Handler struct {
+	// MaxBodySize is the largest payload accepted, in bytes. Zero means
+	// the default of 1MiB.
+	MaxBodySize int64
+
+	// SendCallbacks reports the outcome of the registered callbacks to
+	// the callback_url of each event which has one.
+	SendCallbacks bool
+
+	// HTTPClient is used to send callbacks. If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+
+	// AllowedCallbackHosts are the hosts, with their port if not 443,
+	// which callbacks may be sent to over https. It defaults to
+	// registry.hub.docker.com. Since anyone able to reach the handler can
+	// post an event, events with any other callback_url are rejected
+	// rather than have the handler send requests wherever they point.
+	AllowedCallbackHosts []string
+
+	mu       sync.RWMutex
+	handlers []ResultFunc
+}
+
+// NewHandler returns a new Handler with no callbacks registered.
+func NewHandler() *Handler {
+	return &Handler{MaxBodySize: defaultMaxBodySize}
+}
+
+// OnPush registers fn to be called for every push event.
+func (h *Handler) OnPush(fn HandlerFunc) {
+	h.OnPushResult(func(ctx context.Context, ev *PushEvent) (*CallbackResponse, error) {
+		return nil, fn(ctx, ev)
+	})
+}
+
+// OnPushResult registers fn to be called for every push event. The
+// returned result is sent to the callback_url when SendCallbacks is set.
+func (h *Handler) OnPushResult(fn ResultFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers = append(h.handlers, fn)
+}
+
+// ServeHTTP parses the push event in the request body and dispatches it.
+// Malformed payloads and events whose callback_url is not allowed are
+// answered with 400, and a callback error or a failure to send the
+// callback_url result with 500.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := h.MaxBodySize
+	if limit <= 0 {
+		limit = defaultMaxBodySize
+	}
+
+	ev, err := ParsePushEvent(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.SendCallbacks && ev.CallbackURL != "" {
+		if err := h.checkCallbackURL(ev.CallbackURL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.dispatch(r.Context(), ev)
+
+	if h.SendCallbacks && ev.CallbackURL != "" {
+		if cbErr := SendCallback(r.Context(), h.HTTPClient, ev.CallbackURL, result(results, err)); cbErr != nil && err == nil {
+			err = cbErr
+		}
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkCallbackURL returns an error unless callbacks may be sent to
+// callbackURL.
+func (h *Handler) checkCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return errors.New("callback_url is not allowed")
+	}
+
+	hosts := h.AllowedCallbackHosts
+	if len(hosts) == 0 {
+		hosts = []string{defaultCallbackHost}
+	}
+	for _, host := range hosts {
+		if strings.EqualFold(u.Host, host) {
+			return nil
+		}
+	}
+	return errors.New("callback_url is not allowed")
+}
+
+// dispatch calls the registered callbacks until one fails.
+func (h *Handler) dispatch(ctx context.Context, ev *PushEvent) ([]*CallbackResponse, error) {
+	h.mu.RLock()
+	handlers := h.handlers
+	h.mu.RUnlock()
+
+	results := make([]*CallbackResponse, 0, len(handlers))
+	for _, fn := range handlers {
+		res, err := fn(ctx, ev)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
@@ -0,0 +1,143 @@
+package webhookreceiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const pushPayload = `{
+  "callback_url": "https://registry.hub.docker.com/u/svendowideit/testhook/hook/2141b5bi5i5b02bec211i4eeih0242eg11000a/",
+  "push_data": {
+    "pushed_at": 1417566161,
+    "pusher": "trustedbuilder",
+    "tag": "latest"
+  },
+  "repository": {
+    "comment_count": 0,
+    "date_created": 1417494799,
+    "description": "",
+    "is_official": false,
+    "is_private": true,
+    "is_trusted": true,
+    "name": "testhook",
+    "namespace": "svendowideit",
+    "owner": "svendowideit",
+    "repo_name": "svendowideit/testhook",
+    "repo_url": "https://registry.hub.docker.com/u/svendowideit/testhook/",
+    "star_count": 0,
+    "status": "Active"
+  }
+}`
+
+func TestParsePushEvent(t *testing.T) {
+	ev, err := ParsePushEvent(strings.NewReader(pushPayload))
+	if err != nil {
+		t.Fatalf("ParsePushEvent returned error: %v", err)
+	}
+
+	if got, want := ev.Repository.RepoName, "svendowideit/testhook"; got != want {
+		t.Errorf("repo_name is %s; want %s", got, want)
+	}
+	if got, want := ev.PushData.Tag, "latest"; got != want {
+		t.Errorf("tag is %s; want %s", got, want)
+	}
+	if got, want := ev.PushData.PushedAt.Time, time.Unix(1417566161, 0).UTC(); !got.Equal(want) {
+		t.Errorf("pushed_at is %v; want %v", got, want)
+	}
+}
+
+func TestParsePushEvent_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, body string
+	}{
+		{"malformed", `{"repository":`},
+		{"no repository", `{"push_data":{"tag":"latest"}}`},
+		{"no tag", `{"repository":{"repo_name":"a/b"}}`},
+		{"bad callback", `{"callback_url":"ftp://x","push_data":{"tag":"latest"},"repository":{"repo_name":"a/b"}}`},
+	} {
+		if _, err := ParsePushEvent(strings.NewReader(tc.body)); err == nil {
+			t.Errorf("%s: ParsePushEvent succeeded; want error", tc.name)
+		}
+	}
+}
+
+func TestHandler_Dispatch(t *testing.T) {
+	h := NewHandler()
+
+	var calls []string
+	h.OnPush(func(_ context.Context, ev *PushEvent) error {
+		calls = append(calls, "first:"+ev.Repository.RepoName)
+		return nil
+	})
+	h.OnPush(func(_ context.Context, ev *PushEvent) error {
+		calls = append(calls, "second:"+ev.PushData.Tag)
+		return nil
+	})
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL, "application/json", strings.NewReader(pushPayload))
+	if err != nil {
+		t.Fatalf("POST returned error: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status is %d; want %d", res.StatusCode, http.StatusOK)
+	}
+
+	want := []string{"first:svendowideit/testhook", "second:latest"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls are %v; want %v", calls, want)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h := NewHandler()
+	h.OnPush(func(context.Context, *PushEvent) error {
+		return errors.New("deploy failed")
+	})
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"invalid payload", http.MethodPost, "{}", http.StatusBadRequest},
+		{"callback error", http.MethodPost, pushPayload, http.StatusInternalServerError},
+	} {
+		req, _ := http.NewRequest(tc.method, srv.URL, strings.NewReader(tc.body))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request returned error: %v", tc.name, err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != tc.want {
+			t.Errorf("%s: status is %d; want %d", tc.name, res.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestHandler_MaxBodySize(t *testing.T) {
+	h := NewHandler()
+	h.MaxBodySize = 16
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(pushPayload)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status is %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+}